@@ -0,0 +1,294 @@
+package zstripe
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BulkSource calls fn for every item to process. The object may be nil if the
+// source only has IDs.
+type BulkSource func(fn func(id string, obj json.RawMessage) error) error
+
+// ListSource gets the items from a list endpoint with List.
+func ListSource(path string, params Body) BulkSource {
+	return func(fn func(string, json.RawMessage) error) error {
+		return List(path, params, func(o json.RawMessage) error {
+			var obj ID
+			err := json.Unmarshal(o, &obj)
+			if err != nil {
+				return err
+			}
+			return fn(obj.ID, o)
+		})
+	}
+}
+
+// CSVSource gets the IDs from a column in a CSV file. The first row is
+// skipped if header is true. Empty values are skipped.
+func CSVSource(r io.Reader, column int, header bool) BulkSource {
+	return func(fn func(string, json.RawMessage) error) error {
+		c := csv.NewReader(r)
+		c.FieldsPerRecord = -1
+		for i := 0; ; i++ {
+			row, err := c.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if i == 0 && header {
+				continue
+			}
+			if column >= len(row) {
+				return fmt.Errorf("line %d: no column %d", i+1, column)
+			}
+			if id := strings.TrimSpace(row[column]); id != "" {
+				err := fn(id, nil)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// BulkItem is a single item for a Bulk operation.
+type BulkItem struct {
+	ID     string
+	Object json.RawMessage // May be nil, depending on the source.
+	Key    string          // Idempotency key for this item; see Bulk.
+
+	n int
+}
+
+// Request makes a request with RequestWith, with an Idempotency-Key based on
+// Key. The key includes a counter, so every request the operation makes gets a
+// different key, but the same key as the same request in a previous run.
+func (i *BulkItem) Request(scan interface{}, method, url string, body string) (*http.Response, error) {
+	i.n++
+	return RequestWith(scan, method, url, body, http.Header{
+		"Idempotency-Key": {i.Key + "-" + strconv.Itoa(i.n)},
+	})
+}
+
+// Bulk runs an operation for every item from a source.
+//
+// Every item gets a deterministic idempotency key based on Name and the ID, so
+// re-running an operation after a crash won't do the same request twice (as
+// long as it's within 24 hours, after which Stripe forgets the keys).
+//
+// Stripe also stores the response for requests it rejected with a 4xx error,
+// so items that failed with such an Error get a new key when running the job
+// again. This requires a Checkpoint to record the failures; without it, use a
+// new Name to retry these items. Items that failed with any other error (such
+// as a network error or timeout) keep their key, as Stripe may have already
+// run the request.
+type Bulk struct {
+	Name      string // Name of the job, to create idempotency keys; required.
+	Source    BulkSource
+	Operation func(*BulkItem) error
+
+	Workers int     // Number of items to process concurrently; defaults to 4.
+	Rate    float64 // Maximum number of items per second; 0 is no limit.
+
+	// File to record the IDs of items that were processed successfully, which
+	// are skipped when running the job again, and the IDs of items that Stripe
+	// rejected (prefixed with "!"). The file is created if it doesn't exist.
+	Checkpoint string
+
+	// Write a JSON line with the result for every item to this, if set.
+	Log io.Writer
+}
+
+// BulkResult is the result of Bulk.Run.
+type BulkResult struct {
+	Done, Failed, Skipped int
+}
+
+type bulkLog struct {
+	ID    string    `json:"id"`
+	OK    bool      `json:"ok"`
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Run the operation for all items, until all items are processed or the
+// context is cancelled.
+//
+// Errors from the operation are recorded in the Log and don't stop the run;
+// failed items are retried when running the job again.
+func (b Bulk) Run(ctx context.Context) (BulkResult, error) {
+	var res BulkResult
+	if b.Name == "" {
+		return res, errors.New("zstripe.Bulk.Run: Name must be set")
+	}
+	workers := b.Workers
+	if workers == 0 {
+		workers = 4
+	}
+
+	var (
+		done   = make(map[string]bool)
+		failed = make(map[string]int)
+		cp     *os.File
+	)
+	if b.Checkpoint != "" {
+		var (
+			err  error
+			size int64
+		)
+		done, failed, size, err = readCheckpoint(b.Checkpoint)
+		if err != nil {
+			return res, fmt.Errorf("zstripe.Bulk.Run: %w", err)
+		}
+		cp, err = os.OpenFile(b.Checkpoint, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return res, fmt.Errorf("zstripe.Bulk.Run: %w", err)
+		}
+		defer cp.Close()
+		err = cp.Truncate(size)
+		if err != nil {
+			return res, fmt.Errorf("zstripe.Bulk.Run: %w", err)
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		logErr error
+		wg     sync.WaitGroup
+		items  = make(chan *BulkItem)
+	)
+	record := func(item *BulkItem, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		l := bulkLog{ID: item.ID, OK: err == nil, Time: time.Now().UTC()}
+		line := item.ID
+		if err != nil {
+			res.Failed++
+			l.Error, line = err.Error(), ""
+			if rejected(err) {
+				line = "!" + item.ID
+			}
+		} else {
+			res.Done++
+		}
+		if cp != nil && line != "" {
+			if _, err := fmt.Fprintln(cp, line); err != nil && logErr == nil {
+				logErr = err
+			}
+		}
+		if b.Log != nil {
+			j, _ := json.Marshal(l)
+			if _, err := b.Log.Write(append(j, '\n')); err != nil && logErr == nil {
+				logErr = err
+			}
+		}
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				record(item, b.Operation(item))
+			}
+		}()
+	}
+
+	var limit *bucket
+	if b.Rate > 0 {
+		limit = &bucket{rate: b.Rate, factor: 1, last: time.Now()}
+	}
+	err := b.Source(func(id string, obj json.RawMessage) error {
+		if done[id] {
+			mu.Lock()
+			res.Skipped++
+			mu.Unlock()
+			return nil
+		}
+		if limit != nil {
+			if wait := limit.reserve(time.Now()); wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				}
+			}
+		}
+
+		select {
+		case items <- &BulkItem{ID: id, Object: obj, Key: bulkKey(b.Name, id, failed[id])}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(items)
+	wg.Wait()
+
+	if err != nil {
+		return res, fmt.Errorf("zstripe.Bulk.Run: %w", err)
+	}
+	if logErr != nil {
+		return res, fmt.Errorf("zstripe.Bulk.Run: writing log: %w", logErr)
+	}
+	return res, nil
+}
+
+// rejected reports if Stripe rejected the request with a 4xx error. Stripe
+// stores the response for these, so the item needs a new idempotency key to
+// retry it. Other errors such as network errors and timeouts keep the key, as
+// Stripe may have run the request.
+func rejected(err error) bool {
+	var zErr Error
+	return errors.As(err, &zErr) && zErr.StatusCode >= 400 && zErr.StatusCode < 500
+}
+
+// bulkKey gets the idempotency key for an item which failed the given number
+// of times before.
+func bulkKey(name, id string, failed int) string {
+	if failed == 0 {
+		return name + "-" + id
+	}
+	return name + "-" + id + "-retry" + strconv.Itoa(failed)
+}
+
+// readCheckpoint reads the IDs of done and failed items from the checkpoint
+// file. The last line is ignored if it's not terminated by a newline, as it
+// may have been cut off by a crash; size is the length of the file without it.
+func readCheckpoint(path string) (done map[string]bool, failed map[string]int, size int64, err error) {
+	done, failed = make(map[string]bool), make(map[string]int)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, failed, 0, nil
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	lines := strings.Split(string(b), "\n")
+	for _, id := range lines[:len(lines)-1] {
+		id = strings.TrimSpace(id)
+		switch {
+		case id == "":
+		case id[0] == '!':
+			failed[id[1:]]++
+		default:
+			done[id] = true
+		}
+	}
+	return done, failed, int64(len(b) - len(lines[len(lines)-1])), nil
+}
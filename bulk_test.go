@@ -0,0 +1,141 @@
+package zstripe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBulk(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+		fail = map[string]bool{"cus_3": true}
+		drop = map[string]bool{"cus_4": true}
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/customers" {
+			w.Write([]byte(`{"has_more": false, "data": [{"id": "cus_1"}, {"id": "cus_5"}]}`))
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/v1/customers/")
+		mu.Lock()
+		defer mu.Unlock()
+		if drop[id] {
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if fail[id] {
+			w.WriteHeader(400)
+			w.Write([]byte(`{"error": {"message": "oh noes"}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	// Last line was cut off by a crash; shouldn't skip cus_4.
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	err := os.WriteFile(checkpoint, []byte("cus_2\ncus_"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(src BulkSource) (BulkResult, string) {
+		keys = nil
+		log := new(bytes.Buffer)
+		res, err := Bulk{
+			Name:       "set-meta",
+			Source:     src,
+			Rate:       100,
+			Checkpoint: checkpoint,
+			Log:        log,
+			Operation: func(item *BulkItem) error {
+				for i := 0; i < 2; i++ {
+					_, err := item.Request(nil, "POST", "/v1/customers/"+item.ID, "metadata[x]=1")
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var lines []string
+		for _, l := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+			var e struct {
+				ID    string
+				OK    bool
+				Error string
+			}
+			json.Unmarshal([]byte(l), &e)
+			if strings.HasPrefix(e.Error, "zstripe: client.Do") {
+				e.Error = "zstripe: client.Do"
+			}
+			lines = append(lines, fmt.Sprintf("%s %t %s", e.ID, e.OK, e.Error))
+		}
+		sort.Strings(lines)
+		sort.Strings(keys)
+		return res, strings.Join(lines, "\n") + "\n" + strings.Join(keys, " ")
+	}
+
+	csv := "id,email\ncus_1,a@example.com\ncus_2,b@example.com\ncus_3,c@example.com\n\ncus_4,d@example.com\n"
+	res, got := run(CSVSource(strings.NewReader(csv), 0, true))
+	want := "cus_1 true \ncus_3 false code 400 Bad Request for POST " + api.URL + "/v1/customers/cus_3 (oh noes)\n" +
+		"cus_4 false zstripe: client.Do\n" +
+		"set-meta-cus_1-1 set-meta-cus_1-2 set-meta-cus_3-1"
+	if res != (BulkResult{Done: 1, Failed: 2, Skipped: 1}) {
+		t.Errorf("%+v", res)
+	}
+	if got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Resume: cus_3 should be retried with a new key as Stripe would return
+	// the stored error for the old one, but cus_4 with the same key as Stripe
+	// may have run the request.
+	fail["cus_3"], drop["cus_4"] = false, false
+	res, got = run(CSVSource(strings.NewReader(csv), 0, true))
+	want = "cus_3 true \ncus_4 true \n" +
+		"set-meta-cus_3-retry1-1 set-meta-cus_3-retry1-2 set-meta-cus_4-1 set-meta-cus_4-2"
+	if res != (BulkResult{Done: 2, Skipped: 2}) {
+		t.Errorf("%+v", res)
+	}
+	if got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	res, got = run(ListSource("/v1/customers", nil))
+	want = "cus_5 true \nset-meta-cus_5-1 set-meta-cus_5-2"
+	if res != (BulkResult{Done: 1, Skipped: 1}) {
+		t.Errorf("%+v", res)
+	}
+	if got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	done, failed, _, err := readCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := fmt.Sprint(done, failed); g != "map[cus_1:true cus_2:true cus_3:true cus_4:true cus_5:true] map[cus_3:1]" {
+		t.Errorf("checkpoint: %s", g)
+	}
+}
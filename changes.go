@@ -0,0 +1,134 @@
+package zstripe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is a single changed value in an *.updated event.
+type Change struct {
+	// Path to the value, e.g. "status", "metadata.plan", or
+	// "items.data[0].price.id".
+	Path string
+
+	// Old and new values, as decoded by encoding/json; either may be nil if
+	// the value was added or removed.
+	Old, New interface{}
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v → %v", c.Path, c.Old, c.New)
+}
+
+// Changes compares data.previous_attributes with data.object and returns all
+// values that changed, sorted by path.
+//
+// Nested objects and lists are compared recursively, so this lists the
+// individual values that changed rather than the entire object.
+func (e Event) Changes() ([]Change, error) {
+	if len(e.Data.PreviousAttributes) == 0 {
+		return nil, nil
+	}
+
+	var obj map[string]interface{}
+	err := json.Unmarshal(e.Data.Raw, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("zstripe.Event.Changes: %w", err)
+	}
+
+	var changes []Change
+	for k, old := range e.Data.PreviousAttributes {
+		diff(k, old, obj[k], &changes)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Only the keys in previous_attributes are compared, as nested objects may only
+// contain the changed keys.
+func diff(path string, old, new interface{}, changes *[]Change) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			for k, v := range o {
+				diff(path+"."+k, v, n[k], changes)
+			}
+			return
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			l := len(o)
+			if len(n) > l {
+				l = len(n)
+			}
+			for i := 0; i < l; i++ {
+				var ov, nv interface{}
+				if i < len(o) {
+					ov = o[i]
+				}
+				if i < len(n) {
+					nv = n[i]
+				}
+				diff(path+"["+strconv.Itoa(i)+"]", ov, nv, changes)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Old: old, New: new})
+	}
+}
+
+// Changed reports if the value at path, or anything nested in it, changed.
+//
+// For example Changed("items") is true if "items.data[0].price.id" changed.
+func (e Event) Changed(path string) bool {
+	changes, err := e.Changes()
+	if err != nil {
+		return false
+	}
+	for _, c := range changes {
+		if c.Path == path || strings.HasPrefix(c.Path, path+".") || strings.HasPrefix(c.Path, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedFrom reports if the value at path changed from one value to another,
+// for example:
+//
+//	e.ChangedFrom("status", "trialing", "active")
+//
+// Values are compared by their JSON encoding, so ChangedFrom("quantity", 1, 2)
+// works even though numbers are decoded as float64.
+func (e Event) ChangedFrom(path string, from, to interface{}) bool {
+	changes, err := e.Changes()
+	if err != nil {
+		return false
+	}
+	for _, c := range changes {
+		if c.Path == path {
+			return sameJSON(c.Old, from) && sameJSON(c.New, to)
+		}
+	}
+	return false
+}
+
+func sameJSON(a, b interface{}) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
@@ -0,0 +1,78 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	var e Event
+	err := json.Unmarshal([]byte(`{
+		"type": "customer.subscription.updated",
+		"data": {
+			"object": {
+				"id": "sub_1",
+				"status": "active",
+				"quantity": 2,
+				"metadata": {"a": "1", "b": "new"},
+				"items": {"data": [
+					{"id": "si_1", "price": {"id": "price_new", "unit_amount": 500}},
+					{"id": "si_2", "price": {"id": "price_2"}}
+				]}
+			},
+			"previous_attributes": {
+				"status": "trialing",
+				"quantity": 1,
+				"metadata": {"b": "old", "c": "removed"},
+				"items": {"data": [
+					{"id": "si_1", "price": {"id": "price_old", "unit_amount": 500}}
+				]}
+			}
+		}
+	}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := e.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(changes)
+	want := `[items.data[0].price.id: price_old → price_new items.data[1]: <nil> → map[id:si_2 price:map[id:price_2]] ` +
+		`metadata.b: old → new metadata.c: removed → <nil> quantity: 1 → 2 status: trialing → active]`
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"status", true},
+		{"items", true},
+		{"items.data[0].price", true},
+		{"items.data[0].price.unit_amount", false},
+		{"metadata.a", false},
+		{"stat", false},
+	}
+	for _, tt := range tests {
+		if g := e.Changed(tt.path); g != tt.want {
+			t.Errorf("Changed(%q) = %t", tt.path, g)
+		}
+	}
+
+	if !e.ChangedFrom("status", "trialing", "active") {
+		t.Error("status")
+	}
+	if !e.ChangedFrom("quantity", 1, 2) {
+		t.Error("quantity")
+	}
+	if e.ChangedFrom("status", "active", "trialing") {
+		t.Error("status reversed")
+	}
+	if !e.ChangedFrom("metadata.c", "removed", nil) {
+		t.Error("metadata.c")
+	}
+}
@@ -0,0 +1,114 @@
+// Command zstripe-replay re-delivers Stripe events to a webhook endpoint.
+//
+// It lists the events from /v1/events in the given time window, signs them
+// with the webhook signing secret, and POSTs them to the URL:
+//
+//	export STRIPE_SECRET_KEY=sk_test_...
+//	export STRIPE_SIGN_SECRET=whsec_...
+//	zstripe-replay -from 2021-06-01T12:00:00Z -to 2021-06-01T13:00:00Z \
+//	    -type 'invoice.*,customer.subscription.*' http://localhost:8080/stripe
+//
+// Events listed in the -processed file (one ID per line) are skipped.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"zgo.at/zstripe"
+)
+
+func main() {
+	var (
+		from      = flag.String("from", "", "Replay events created since this time (RFC 3339); required.")
+		to        = flag.String("to", "", "Replay events created until this time (RFC 3339); default is now.")
+		types     = flag.String("type", "", "Comma-separated list of event types to replay; may contain * wildcards.")
+		processed = flag.String("processed", "", "File with event IDs to skip, one per line.")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] url\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *from == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	zstripe.SecretKey = os.Getenv("STRIPE_SECRET_KEY")
+	zstripe.SignSecret = os.Getenv("STRIPE_SIGN_SECRET")
+	if zstripe.SecretKey == "" || zstripe.SignSecret == "" {
+		fatal("must set STRIPE_SECRET_KEY and STRIPE_SIGN_SECRET")
+	}
+
+	r := zstripe.Replay{URL: flag.Arg(0)}
+	var err error
+	r.From, err = time.Parse(time.RFC3339, *from)
+	if err != nil {
+		fatal("-from: %s", err)
+	}
+	if *to != "" {
+		r.To, err = time.Parse(time.RFC3339, *to)
+		if err != nil {
+			fatal("-to: %s", err)
+		}
+	}
+	if *types != "" {
+		r.Types = strings.Split(*types, ",")
+	}
+	if *processed != "" {
+		r.Store, err = readProcessed(*processed)
+		if err != nil {
+			fatal("-processed: %s", err)
+		}
+	}
+
+	res, err := r.Run()
+	if err != nil {
+		fatal("%s", err)
+	}
+
+	fmt.Printf("delivered %d, skipped %d, failed %d\n", len(res.Delivered), len(res.Skipped), len(res.Failed))
+	if len(res.Failed) > 0 {
+		ids := make([]string, 0, len(res.Failed))
+		for id := range res.Failed {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("  %s: %s\n", id, res.Failed[id])
+		}
+		os.Exit(1)
+	}
+}
+
+func readProcessed(path string) (zstripe.EventStore, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var (
+		store = &zstripe.MemoryStore{}
+		now   = time.Now()
+		scan  = bufio.NewScanner(fp)
+	)
+	for scan.Scan() {
+		if id := strings.TrimSpace(scan.Text()); id != "" {
+			store.Claim(id, now, now)
+			store.Finish(id, zstripe.EventDone, now)
+		}
+	}
+	return store, scan.Err()
+}
+
+func fatal(msg string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "zstripe-replay: "+msg+"\n", args...)
+	os.Exit(1)
+}
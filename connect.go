@@ -0,0 +1,115 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// AccountRequest makes a request with Request, on behalf of the account that
+// originated the event.
+//
+// This sets the Stripe-Account header for events from connected accounts, and
+// is identical to Request for events from your own account.
+func (e Event) AccountRequest(scan interface{}, method, url string, body string) (*http.Response, error) {
+	if e.Account == "" {
+		return Request(scan, method, url, body)
+	}
+	return RequestWith(scan, method, url, body, http.Header{"Stripe-Account": {e.Account}})
+}
+
+// ConnectHandler handles webhooks for Connect platforms, dispatching events to
+// a handler by the account that originated them.
+//
+// Events can be signed with the platform's secret for the Connect endpoint, or
+// with a per-account secret if connected accounts have their own endpoint.
+// Events with an account that doesn't match the secret's account are rejected.
+//
+// Use Event.AccountRequest in the handlers to make requests on behalf of the account.
+type ConnectHandler struct {
+	// Signing secret for the platform's endpoint; used for events from your
+	// own account and connected accounts not in Secrets. Defaults to
+	// SignSecret.
+	Secret string
+
+	// Signing secrets for specific accounts.
+	Secrets map[string]string
+
+	// Handlers for specific accounts; events from other accounts are sent to
+	// Default. Events for which there is no handler are acknowledged and
+	// ignored.
+	Handlers map[string]EventHandler
+	Default  EventHandler
+
+	// Verifier to use. The Secret is set from the above, and the Account (or
+	// WebhookAccount) isn't checked, as the handler accepts events from all
+	// accounts.
+	Verifier Verifier
+}
+
+// Read the event from the request and validate it.
+func (c ConnectHandler) Read(r *http.Request) (Event, error) {
+	var e Event
+	b, err := readBody(r)
+	if err != nil {
+		return e, err
+	}
+
+	// The account isn't verified yet, but it's only used to select the secret.
+	// If someone sends a different account the signature won't match.
+	var acct struct {
+		Account string `json:"account"`
+	}
+	err = json.Unmarshal(b, &acct)
+	if err != nil {
+		return e, fmt.Errorf("zstripe.ConnectHandler: %w", err)
+	}
+
+	v := c.Verifier
+	v.Secret = c.Secret
+	if s, ok := c.Secrets[acct.Account]; ok {
+		v.Secret = s
+	}
+	_, err = v.Verify(r.Header.Get("Stripe-Signature"), b)
+	if err != nil {
+		return e, err
+	}
+
+	err = e.decode(b)
+	if err != nil {
+		return e, err
+	}
+	if e.Account != acct.Account {
+		return e, fmt.Errorf("%w: %q", ErrWebhookAccount, e.Account)
+	}
+	return e, v.checkMode(e.Livemode)
+}
+
+// Handler gets the handler for the account.
+func (c ConnectHandler) Handler(account string) EventHandler {
+	if h, ok := c.Handlers[account]; ok {
+		return h
+	}
+	return c.Default
+}
+
+// ServeHTTP reads the event and runs the handler for the account.
+//
+// This responds with 400 Bad Request if the event can't be read or verified,
+// and 500 Internal Server Error if the handler returns an error.
+func (c ConnectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e, err := c.Read(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h := c.Handler(e.Account); h != nil {
+		err = h(e)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,84 @@
+package zstripe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectHandler(t *testing.T) {
+	var got []string
+	h := ConnectHandler{
+		Secret:  "whsec_platform",
+		Secrets: map[string]string{"acct_own": "whsec_own"},
+		Handlers: map[string]EventHandler{
+			"acct_fail": func(e Event) error { return fmt.Errorf("oh noes") },
+		},
+		Default: func(e Event) error {
+			got = append(got, e.ID+" "+e.Account)
+			return nil
+		},
+	}
+
+	sign := func(secret string, payload string) string {
+		now := time.Now()
+		return fmt.Sprintf("t=%d,v1=%x", now.Unix(), signature(secret, now, []byte(payload)))
+	}
+
+	tests := []struct {
+		payload, secret string
+		wantCode        int
+		wantGot         string
+	}{
+		{`{"id":"evt_1"}`, "whsec_platform", 200, "evt_1 "},
+		{`{"id":"evt_2","account":"acct_1"}`, "whsec_platform", 200, "evt_2 acct_1"},
+		{`{"id":"evt_3","account":"acct_own"}`, "whsec_own", 200, "evt_3 acct_own"},
+		{`{"id":"evt_4","account":"acct_own"}`, "whsec_platform", 400, ""},
+		{`{"id":"evt_5","account":"acct_1"}`, "whsec_own", 400, ""},
+		{`{"id":"evt_6","account":"acct_fail"}`, "whsec_platform", 500, ""},
+	}
+
+	// Shouldn't reject events from connected accounts.
+	WebhookAccount = "acct_platform"
+	defer func() { WebhookAccount = "" }()
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got = nil
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.payload))
+			r.Header.Set("Stripe-Signature", sign(tt.secret, tt.payload))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("code %d: %s", w.Code, w.Body)
+			}
+			if g := strings.Join(got, ""); g != tt.wantGot {
+				t.Errorf("\ngot:  %q\nwant: %q", g, tt.wantGot)
+			}
+		})
+	}
+}
+
+func TestEventAccountRequest(t *testing.T) {
+	var acct []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acct = append(acct, r.Header.Get("Stripe-Account"))
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	for _, e := range []Event{{}, {Account: "acct_1"}} {
+		_, err := e.AccountRequest(nil, "GET", "/v1/customers/cus_1", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fmt.Sprint(acct) != "[ acct_1]" {
+		t.Errorf("%q", acct)
+	}
+}
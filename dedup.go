@@ -0,0 +1,273 @@
+package zstripe
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Possible errors from Dedup.Do.
+var (
+	// Event was already processed successfully; you usually want to respond
+	// with 200 OK so Stripe won't send it again.
+	ErrEventProcessed = errors.New("zstripe.Dedup: event already processed")
+
+	// Event is being processed by someone else; you usually want to respond
+	// with an error so Stripe will retry it later.
+	ErrEventInProgress = errors.New("zstripe.Dedup: event is being processed")
+)
+
+// EventStatus is the processing status of an event in an EventStore.
+type EventStatus string
+
+// Possible event statuses.
+const (
+	EventProcessing EventStatus = "processing"
+	EventDone       EventStatus = "done"
+	EventFailed     EventStatus = "failed"
+)
+
+// EventStore records the IDs of processed events.
+type EventStore interface {
+	// Claim the event for processing, and set the status to EventProcessing.
+	//
+	// This must be atomic, so that only one caller can claim an event. Events
+	// that are not yet in the store, events with EventFailed, and events with
+	// EventProcessing that were last updated before stale can be claimed.
+	//
+	// The current status is returned if the event can't be claimed.
+	Claim(id string, now, stale time.Time) (bool, EventStatus, error)
+
+	// Finish processing of a claimed event, setting the status to either
+	// EventDone or EventFailed. Events that are no longer EventProcessing are
+	// left alone.
+	Finish(id string, status EventStatus, now time.Time) error
+
+	// Status gets the status for an event, or an empty string if the event
+	// isn't in the store.
+	Status(id string) (EventStatus, error)
+
+	// Expire removes all events last updated before the given time.
+	Expire(before time.Time) error
+}
+
+// Dedup makes sure events are processed only once.
+//
+// Stripe delivers events "at least once", so the same event may be sent more
+// than once, possibly at the same time.
+type Dedup struct {
+	Store EventStore
+
+	// Events that have been processing for longer than this are assumed to
+	// have failed (e.g. because the process crashed) and can be claimed again.
+	// Defaults to 5 minutes.
+	Timeout time.Duration
+}
+
+// Do runs fn for the event, unless it was already processed or is being
+// processed.
+//
+// This will return ErrEventProcessed or ErrEventInProgress if fn wasn't run,
+// and the error from fn otherwise. The event is marked as EventFailed if fn
+// returns an error, and it will be run again on the next delivery.
+func (d Dedup) Do(e Event, fn EventHandler) error {
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+
+	now := time.Now()
+	ok, status, err := d.Store.Claim(e.ID, now, now.Add(-timeout))
+	if err != nil {
+		return fmt.Errorf("zstripe.Dedup: %w", err)
+	}
+	if !ok {
+		if status == EventDone {
+			return ErrEventProcessed
+		}
+		return ErrEventInProgress
+	}
+
+	fnErr := fn(e)
+	status = EventDone
+	if fnErr != nil {
+		status = EventFailed
+	}
+	err = d.Store.Finish(e.ID, status, time.Now())
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("zstripe.Dedup: %w", err)
+	}
+	return nil
+}
+
+// Wrap the handler so it runs through Do.
+//
+// ErrEventProcessed is not returned from the returned handler, since there is
+// nothing to do.
+func (d Dedup) Wrap(fn EventHandler) EventHandler {
+	return func(e Event) error {
+		err := d.Do(e, fn)
+		if errors.Is(err, ErrEventProcessed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// Expire removes all events older than age.
+//
+// Stripe retries events for up to three days, so there's little point in
+// keeping them much longer than that.
+func (d Dedup) Expire(age time.Duration) error {
+	err := d.Store.Expire(time.Now().Add(-age))
+	if err != nil {
+		return fmt.Errorf("zstripe.Dedup.Expire: %w", err)
+	}
+	return nil
+}
+
+// MemoryStore is an in-memory EventStore.
+//
+// The zero value is safe to use.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events map[string]memoryEvent
+}
+
+type memoryEvent struct {
+	status  EventStatus
+	updated time.Time
+}
+
+var _ EventStore = &MemoryStore{}
+
+func (m *MemoryStore) Claim(id string, now, stale time.Time) (bool, EventStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.events == nil {
+		m.events = make(map[string]memoryEvent)
+	}
+
+	ev, ok := m.events[id]
+	if ok && ev.status == EventDone {
+		return false, ev.status, nil
+	}
+	if ok && ev.status == EventProcessing && !ev.updated.Before(stale) {
+		return false, ev.status, nil
+	}
+	m.events[id] = memoryEvent{status: EventProcessing, updated: now}
+	return true, EventProcessing, nil
+}
+
+func (m *MemoryStore) Finish(id string, status EventStatus, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.events[id].status != EventProcessing {
+		return nil
+	}
+	m.events[id] = memoryEvent{status: status, updated: now}
+	return nil
+}
+
+func (m *MemoryStore) Status(id string) (EventStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.events[id].status, nil
+}
+
+func (m *MemoryStore) Expire(before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, ev := range m.events {
+		if ev.updated.Before(before) {
+			delete(m.events, id)
+		}
+	}
+	return nil
+}
+
+// SQLStore is an EventStore backed by database/sql.
+//
+// The table must exist; see Schema().
+type SQLStore struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string // Table name; defaults to "zstripe_events".
+}
+
+var _ EventStore = SQLStore{}
+
+func (s SQLStore) table() string {
+	if s.Table == "" {
+		return "zstripe_events"
+	}
+	return s.Table
+}
+
+func (s SQLStore) query(q string) string {
+	return s.Dialect.rebind(fmt.Sprintf(q, s.table()))
+}
+
+// Schema gets the CREATE TABLE statement for this store.
+func (s SQLStore) Schema() string {
+	return fmt.Sprintf(`create table %s (
+	id          varchar  not null primary key,
+	status      varchar  not null,
+	updated_at  bigint   not null
+);
+create index %[1]s_updated_at on %[1]s(updated_at);
+`, s.table())
+}
+
+func (s SQLStore) Claim(id string, now, stale time.Time) (bool, EventStatus, error) {
+	res, err := s.DB.Exec(s.query(`insert into %s (id, status, updated_at) values (?, ?, ?) on conflict (id) do nothing`),
+		id, EventProcessing, now.Unix())
+	if err != nil {
+		return false, "", err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return false, "", err
+	} else if n == 1 {
+		return true, EventProcessing, nil
+	}
+
+	res, err = s.DB.Exec(s.query(`update %s set status=?, updated_at=?
+		where id=? and (status=? or (status=? and updated_at<?))`),
+		EventProcessing, now.Unix(), id, EventFailed, EventProcessing, stale.Unix())
+	if err != nil {
+		return false, "", err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return false, "", err
+	} else if n == 1 {
+		return true, EventProcessing, nil
+	}
+
+	status, err := s.Status(id)
+	return false, status, err
+}
+
+func (s SQLStore) Finish(id string, status EventStatus, now time.Time) error {
+	_, err := s.DB.Exec(s.query(`update %s set status=?, updated_at=? where id=? and status=?`),
+		status, now.Unix(), id, EventProcessing)
+	return err
+}
+
+func (s SQLStore) Status(id string) (EventStatus, error) {
+	var status EventStatus
+	err := s.DB.QueryRow(s.query(`select status from %s where id=?`), id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return status, err
+}
+
+func (s SQLStore) Expire(before time.Time) error {
+	_, err := s.DB.Exec(s.query(`delete from %s where updated_at<?`), before.Unix())
+	return err
+}
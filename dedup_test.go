@@ -0,0 +1,164 @@
+package zstripe
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	d := Dedup{Store: &MemoryStore{}}
+	e := Event{ID: "evt_1"}
+
+	var n int32
+	fn := func(Event) error {
+		atomic.AddInt32(&n, 1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		inProg   int32
+		dupCount int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := d.Do(e, fn)
+			switch {
+			case errors.Is(err, ErrEventInProgress):
+				atomic.AddInt32(&inProg, 1)
+			case errors.Is(err, ErrEventProcessed):
+				atomic.AddInt32(&dupCount, 1)
+			case err != nil:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n != 1 {
+		t.Fatalf("handler ran %d times", n)
+	}
+	if inProg+dupCount != 9 {
+		t.Fatalf("inProg=%d dupCount=%d", inProg, dupCount)
+	}
+
+	err := d.Do(e, fn)
+	if !errors.Is(err, ErrEventProcessed) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	err = d.Wrap(fn)(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupFailed(t *testing.T) {
+	d := Dedup{Store: &MemoryStore{}}
+	e := Event{ID: "evt_1"}
+
+	fail := errors.New("oh noes")
+	err := d.Do(e, func(Event) error { return fail })
+	if !errors.Is(err, fail) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if s, _ := d.Store.Status(e.ID); s != EventFailed {
+		t.Fatalf("wrong status: %q", s)
+	}
+
+	err = d.Do(e, func(Event) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := d.Store.Status(e.ID); s != EventDone {
+		t.Fatalf("wrong status: %q", s)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		m   MemoryStore
+		now = time.Now()
+	)
+
+	ok, _, _ := m.Claim("evt_1", now, now.Add(-time.Minute))
+	if !ok {
+		t.Fatal("not claimed")
+	}
+
+	ok, status, _ := m.Claim("evt_1", now, now.Add(-time.Minute))
+	if ok || status != EventProcessing {
+		t.Fatalf("claimed twice: %v %q", ok, status)
+	}
+
+	// Stale.
+	ok, _, _ = m.Claim("evt_1", now.Add(2*time.Minute), now.Add(time.Minute))
+	if !ok {
+		t.Fatal("stale event not claimed")
+	}
+
+	m.Expire(now.Add(time.Hour))
+	if s, _ := m.Status("evt_1"); s != "" {
+		t.Fatalf("not expired: %q", s)
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	s := SQLStore{}
+	s.DB = testDB(t, s.Schema())
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	claim := func(at time.Time, want bool, wantStatus EventStatus) {
+		t.Helper()
+		ok, status, err := s.Claim("evt_1", at, at.Add(-time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want || status != wantStatus {
+			t.Fatalf("claim: %t %q; want %t %q", ok, status, want, wantStatus)
+		}
+	}
+	status := func(want EventStatus) {
+		t.Helper()
+		if s, err := s.Status("evt_1"); err != nil || s != want {
+			t.Fatalf("status: %q %v; want %q", s, err, want)
+		}
+	}
+
+	status("")
+	claim(now, true, EventProcessing)
+	claim(now.Add(30*time.Second), false, EventProcessing) // Not stale yet.
+
+	// Failed events can be claimed again.
+	if err := s.Finish("evt_1", EventFailed, now); err != nil {
+		t.Fatal(err)
+	}
+	status(EventFailed)
+	claim(now.Add(time.Second), true, EventProcessing)
+
+	// Stale events can be claimed again.
+	claim(now.Add(2*time.Minute), true, EventProcessing)
+
+	if err := s.Finish("evt_1", EventDone, now.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	status(EventDone)
+	claim(now.Add(time.Hour), false, EventDone)
+
+	// Finish shouldn't change events that are no longer processing, e.g. from
+	// a handler which took longer than the timeout.
+	if err := s.Finish("evt_1", EventFailed, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	status(EventDone)
+
+	if err := s.Expire(now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	status("")
+}
@@ -0,0 +1,269 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// WebhookEndpoint is the desired configuration of a webhook endpoint.
+type WebhookEndpoint struct {
+	URL           string
+	EnabledEvents []string // Event types, or "*" for all events.
+	Description   string
+	Metadata      map[string]string
+
+	// API version to render events with; defaults to the account's version if
+	// empty. This can't be changed on existing endpoints, so a new endpoint is
+	// created and the old one deleted if it's different.
+	APIVersion string
+}
+
+// Possible actions for WebhookChange.
+const (
+	WebhookCreate = "create"
+	WebhookUpdate = "update"
+	WebhookDelete = "delete"
+)
+
+// WebhookChange is a change to converge the webhook endpoints to the desired
+// configuration.
+type WebhookChange struct {
+	Action   string          // WebhookCreate, WebhookUpdate, or WebhookDelete.
+	ID       string          // ID of the existing endpoint; empty for WebhookCreate.
+	Endpoint WebhookEndpoint // Desired configuration, or the current one for WebhookDelete.
+	Fields   []string        // Changed fields for WebhookUpdate.
+}
+
+func (c WebhookChange) String() string {
+	switch c.Action {
+	case WebhookCreate:
+		return fmt.Sprintf("create %s", c.Endpoint.URL)
+	case WebhookUpdate:
+		return fmt.Sprintf("update %s %s: %v", c.ID, c.Endpoint.URL, c.Fields)
+	default:
+		return fmt.Sprintf("%s %s %s", c.Action, c.ID, c.Endpoint.URL)
+	}
+}
+
+type webhookEndpoint struct {
+	ID            string            `json:"id"`
+	URL           string            `json:"url"`
+	Status        string            `json:"status"`
+	Created       int64             `json:"created"`
+	EnabledEvents []string          `json:"enabled_events"`
+	APIVersion    string            `json:"api_version"`
+	Description   string            `json:"description"`
+	Metadata      map[string]string `json:"metadata"`
+	Secret        string            `json:"secret"` // Only on create.
+}
+
+// DiffWebhookEndpoints compares the desired endpoints to the existing ones in
+// /v1/webhook_endpoints, and returns the changes needed to converge them.
+//
+// Endpoints are matched by URL. If there are several endpoints for a URL the
+// enabled one is kept, then the oldest one, and the others are deleted.
+// Disabled endpoints are enabled again.
+//
+// Existing endpoints with a URL not in want are only deleted if prune is set;
+// don't use this if the account has endpoints managed by something else, such
+// as other applications or environments.
+func DiffWebhookEndpoints(want []WebhookEndpoint, prune bool) ([]WebhookChange, error) {
+	have := make(map[string][]webhookEndpoint)
+	err := List("/v1/webhook_endpoints", Body{"limit": "100"}, func(o json.RawMessage) error {
+		var ep webhookEndpoint
+		err := json.Unmarshal(o, &ep)
+		if err != nil {
+			return err
+		}
+		have[ep.URL] = append(have[ep.URL], ep)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("zstripe.DiffWebhookEndpoints: %w", err)
+	}
+	for _, eps := range have {
+		sort.Slice(eps, func(i, j int) bool {
+			if a, b := eps[i].Status != "disabled", eps[j].Status != "disabled"; a != b {
+				return a
+			}
+			if eps[i].Created != eps[j].Created {
+				return eps[i].Created < eps[j].Created
+			}
+			return eps[i].ID < eps[j].ID
+		})
+	}
+
+	var (
+		changes []WebhookChange
+		seen    = make(map[string]bool)
+	)
+	for _, w := range want {
+		if seen[w.URL] {
+			return nil, fmt.Errorf("zstripe.DiffWebhookEndpoints: duplicate URL %q", w.URL)
+		}
+		seen[w.URL] = true
+
+		eps, ok := have[w.URL]
+		if !ok {
+			changes = append(changes, WebhookChange{Action: WebhookCreate, Endpoint: w})
+			continue
+		}
+		// Create the new endpoint before deleting the old one, so no events are
+		// lost in between, and the old one is kept if creating fails.
+		h := eps[0]
+		if w.APIVersion != "" && w.APIVersion != h.APIVersion {
+			changes = append(changes,
+				WebhookChange{Action: WebhookCreate, Endpoint: w},
+				WebhookChange{Action: WebhookDelete, ID: h.ID, Endpoint: h.endpoint()})
+		} else {
+			var fields []string
+			if !sameSet(w.EnabledEvents, h.EnabledEvents) {
+				fields = append(fields, "enabled_events")
+			}
+			if w.Description != h.Description {
+				fields = append(fields, "description")
+			}
+			if !sameMap(w.Metadata, h.Metadata) {
+				fields = append(fields, "metadata")
+			}
+			if h.Status == "disabled" {
+				fields = append(fields, "disabled")
+			}
+			if len(fields) > 0 {
+				changes = append(changes, WebhookChange{Action: WebhookUpdate, ID: h.ID, Endpoint: w, Fields: fields})
+			}
+		}
+		for _, d := range eps[1:] { // Duplicate URL.
+			changes = append(changes, WebhookChange{Action: WebhookDelete, ID: d.ID, Endpoint: d.endpoint()})
+		}
+	}
+
+	if prune {
+		urls := make([]string, 0, len(have))
+		for u := range have {
+			urls = append(urls, u)
+		}
+		sort.Strings(urls)
+		for _, u := range urls {
+			if !seen[u] {
+				for _, d := range have[u] {
+					changes = append(changes, WebhookChange{Action: WebhookDelete, ID: d.ID, Endpoint: d.endpoint()})
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+// ApplyWebhookChanges applies the changes from DiffWebhookEndpoints.
+//
+// The signing secrets (whsec_*) for created endpoints are returned, indexed by
+// URL. Stripe only returns these on creation, so make sure to store them. On
+// errors the secrets for the endpoints created so far are still returned.
+func ApplyWebhookChanges(changes []WebhookChange) (map[string]string, error) {
+	secrets := make(map[string]string)
+	for _, c := range changes {
+		switch c.Action {
+		case WebhookDelete:
+			_, err := Request(nil, "DELETE", "/v1/webhook_endpoints/"+url.PathEscape(c.ID), "")
+			if err != nil {
+				return secrets, fmt.Errorf("zstripe.ApplyWebhookChanges: %s: %w", c, err)
+			}
+
+		case WebhookCreate:
+			b := c.Endpoint.body(nil)
+			b["url"] = c.Endpoint.URL
+			if c.Endpoint.APIVersion != "" {
+				b["api_version"] = c.Endpoint.APIVersion
+			}
+			var ep webhookEndpoint
+			_, err := Request(&ep, "POST", "/v1/webhook_endpoints", b.Encode())
+			if err != nil {
+				return secrets, fmt.Errorf("zstripe.ApplyWebhookChanges: %s: %w", c, err)
+			}
+			secrets[c.Endpoint.URL] = ep.Secret
+
+		case WebhookUpdate:
+			var cur webhookEndpoint
+			_, err := Request(&cur, "GET", "/v1/webhook_endpoints/"+url.PathEscape(c.ID), "")
+			if err != nil {
+				return secrets, fmt.Errorf("zstripe.ApplyWebhookChanges: %s: %w", c, err)
+			}
+			b := c.Endpoint.body(cur.Metadata)
+			b["disabled"] = "false"
+			_, err = Request(nil, "POST", "/v1/webhook_endpoints/"+url.PathEscape(c.ID), b.Encode())
+			if err != nil {
+				return secrets, fmt.Errorf("zstripe.ApplyWebhookChanges: %s: %w", c, err)
+			}
+
+		default:
+			return secrets, fmt.Errorf("zstripe.ApplyWebhookChanges: unknown action %q", c.Action)
+		}
+	}
+	return secrets, nil
+}
+
+// SyncWebhookEndpoints converges the webhook endpoints to the desired
+// configuration; this is DiffWebhookEndpoints and ApplyWebhookChanges.
+func SyncWebhookEndpoints(want []WebhookEndpoint, prune bool) (map[string]string, error) {
+	changes, err := DiffWebhookEndpoints(want, prune)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyWebhookChanges(changes)
+}
+
+// body gets the request body for creating or updating the endpoint; metadata
+// keys in oldMeta but not in the endpoint are unset.
+func (w WebhookEndpoint) body(oldMeta map[string]string) Body {
+	b := Body{"description": w.Description}
+	for i, e := range w.EnabledEvents {
+		b["enabled_events["+strconv.Itoa(i)+"]"] = e
+	}
+	for k := range oldMeta {
+		b["metadata["+k+"]"] = ""
+	}
+	for k, v := range w.Metadata {
+		b["metadata["+k+"]"] = v
+	}
+	return b
+}
+
+func (ep webhookEndpoint) endpoint() WebhookEndpoint {
+	return WebhookEndpoint{
+		URL:           ep.URL,
+		EnabledEvents: ep.EnabledEvents,
+		Description:   ep.Description,
+		Metadata:      ep.Metadata,
+		APIVersion:    ep.APIVersion,
+	}
+}
+
+func sameSet(a, b []string) bool {
+	m := make(map[string]bool, len(a))
+	for _, s := range a {
+		m[s] = true
+	}
+	for _, s := range b {
+		if !m[s] {
+			return false
+		}
+		delete(m, s)
+	}
+	return len(m) == 0
+}
+
+func sameMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSyncWebhookEndpoints(t *testing.T) {
+	var (
+		n         = 0
+		reqs      []string
+		endpoints = map[string]*webhookEndpoint{
+			"we_old": {ID: "we_old", URL: "https://example.com/old", EnabledEvents: []string{"*"}},
+			"we_upd": {ID: "we_upd", URL: "https://example.com/upd", EnabledEvents: []string{EventInvoicePaid},
+				Metadata: map[string]string{"a": "1", "b": "2"}, APIVersion: "2020-08-27"},
+			"we_ver": {ID: "we_ver", URL: "https://example.com/ver", EnabledEvents: []string{"*"}, APIVersion: "2019-01-01"},
+			"we_ok": {ID: "we_ok", URL: "https://example.com/ok", EnabledEvents: []string{"*"}, Description: "x",
+				Metadata: map[string]string{}},
+			"we_dis": {ID: "we_dis", URL: "https://example.com/dis", EnabledEvents: []string{"*"}, Status: "disabled"},
+
+			// Keep the enabled one, then the oldest one, then the lowest ID.
+			"we_dup1": {ID: "we_dup1", URL: "https://example.com/dup", EnabledEvents: []string{"*"}, Created: 3},
+			"we_dup2": {ID: "we_dup2", URL: "https://example.com/dup", EnabledEvents: []string{"*"}, Created: 1,
+				Status: "disabled"},
+			"we_dup3": {ID: "we_dup3", URL: "https://example.com/dup", EnabledEvents: []string{"*"}, Created: 2},
+			"we_dup4": {ID: "we_dup4", URL: "https://example.com/dup", EnabledEvents: []string{"*"}, Created: 2},
+		}
+	)
+	for _, ep := range endpoints {
+		if ep.Status == "" {
+			ep.Status = "enabled"
+		}
+	}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id := strings.TrimPrefix(r.URL.Path, "/v1/webhook_endpoints/")
+		if r.Method != "GET" {
+			reqs = append(reqs, r.Method+" "+id+" "+r.Form.Get("url"))
+		}
+		switch {
+		case r.Method == "GET" && id == "/v1/webhook_endpoints":
+			var list []*webhookEndpoint
+			for _, ep := range endpoints {
+				list = append(list, ep)
+			}
+			sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+			j, _ := json.Marshal(map[string]interface{}{"data": list})
+			w.Write(j)
+		case r.Method == "GET":
+			j, _ := json.Marshal(endpoints[id])
+			w.Write(j)
+		case r.Method == "DELETE":
+			delete(endpoints, id)
+		case r.Method == "POST":
+			ep := endpoints[id]
+			if ep == nil {
+				n++
+				ep = &webhookEndpoint{ID: fmt.Sprintf("we_new%d", n), URL: r.Form.Get("url"), Status: "enabled",
+					APIVersion: r.Form.Get("api_version"), Secret: fmt.Sprintf("whsec_%d", n)}
+				endpoints[ep.ID] = ep
+			}
+			if r.Form.Get("disabled") == "false" {
+				ep.Status = "enabled"
+			}
+			ep.EnabledEvents, ep.Metadata = nil, map[string]string{}
+			for k, v := range r.Form {
+				switch {
+				case strings.HasPrefix(k, "enabled_events["):
+					ep.EnabledEvents = append(ep.EnabledEvents, v[0])
+				case strings.HasPrefix(k, "metadata[") && v[0] != "":
+					ep.Metadata[strings.TrimSuffix(strings.TrimPrefix(k, "metadata["), "]")] = v[0]
+				case k == "description":
+					ep.Description = v[0]
+				}
+			}
+			j, _ := json.Marshal(ep)
+			w.Write(j)
+		}
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	want := []WebhookEndpoint{
+		{URL: "https://example.com/new", EnabledEvents: []string{EventInvoicePaid}},
+		{URL: "https://example.com/upd", EnabledEvents: []string{EventInvoicePaid, EventInvoicePaymentFailed},
+			Metadata: map[string]string{"a": "1"}},
+		{URL: "https://example.com/ver", EnabledEvents: []string{"*"}, APIVersion: "2020-08-27"},
+		{URL: "https://example.com/ok", EnabledEvents: []string{"*"}, Description: "x"},
+		{URL: "https://example.com/dis", EnabledEvents: []string{"*"}},
+		{URL: "https://example.com/dup", EnabledEvents: []string{"*"}},
+	}
+
+	diff := func(prune bool, want []WebhookEndpoint) []WebhookChange {
+		t.Helper()
+		changes, err := DiffWebhookEndpoints(want, prune)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changes
+	}
+	str := func(changes []WebhookChange) []string {
+		var s []string
+		for _, c := range changes {
+			s = append(s, c.String())
+		}
+		return s
+	}
+
+	wantChanges := []string{
+		"create https://example.com/new",
+		"update we_upd https://example.com/upd: [enabled_events metadata]",
+		"create https://example.com/ver",
+		"delete we_ver https://example.com/ver",
+		"update we_dis https://example.com/dis: [disabled]",
+		"delete we_dup4 https://example.com/dup",
+		"delete we_dup1 https://example.com/dup",
+		"delete we_dup2 https://example.com/dup",
+	}
+	if got := str(diff(false, want)); !reflect.DeepEqual(got, wantChanges) {
+		t.Fatalf("\ngot:  %q\nwant: %q", got, wantChanges)
+	}
+
+	changes := diff(true, want)
+	wantChanges = append(wantChanges, "delete we_old https://example.com/old")
+	if got := str(changes); !reflect.DeepEqual(got, wantChanges) {
+		t.Fatalf("\ngot:  %q\nwant: %q", got, wantChanges)
+	}
+
+	secrets, err := ApplyWebhookChanges(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReqs := []string{
+		"POST /v1/webhook_endpoints https://example.com/new",
+		"POST we_upd ",
+		"POST /v1/webhook_endpoints https://example.com/ver",
+		"DELETE we_ver ",
+		"POST we_dis ",
+		"DELETE we_dup4 ",
+		"DELETE we_dup1 ",
+		"DELETE we_dup2 ",
+		"DELETE we_old ",
+	}
+	if !reflect.DeepEqual(reqs, wantReqs) {
+		t.Errorf("\ngot:  %q\nwant: %q", reqs, wantReqs)
+	}
+	if !reflect.DeepEqual(secrets, map[string]string{
+		"https://example.com/new": "whsec_1",
+		"https://example.com/ver": "whsec_2",
+	}) {
+		t.Errorf("secrets: %v", secrets)
+	}
+
+	if changes := diff(true, want); len(changes) > 0 {
+		t.Errorf("not converged: %v", changes)
+	}
+}
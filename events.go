@@ -148,10 +148,12 @@ const (
 	// Customer's subscription ends.
 	EventCustomerSubscriptionDeleted = "customer.subscription.deleted"
 
-	// Customer's subscription's pending update is applied, and the subscription is updated.
+	// Customer's subscription's pending update is applied, and the subscription
+	// is updated.
 	EventCustomerSubscriptionPendingUpdateApplied = "customer.subscription.pending_update_applied"
 
-	// Customer's subscription's pending update expires before the related invoice is paid.
+	// Customer's subscription's pending update expires before the related
+	// invoice is paid.
 	EventCustomerSubscriptionPendingUpdateExpired = "customer.subscription.pending_update_expired"
 
 	// Three days before a subscription's trial period is scheduled to end, or
@@ -181,7 +183,8 @@ const (
 	// Draft invoice is deleted.
 	EventInvoiceDeleted = "invoice.deleted"
 
-	// Draft invoice cannot be finalized. See the invoice’s last finalization error for details.
+	// Draft invoice cannot be finalized. See the invoice’s last finalization
+	// error for details.
 	EventInvoiceFinalizationFailed = "invoice.finalization_failed"
 
 	// Draft invoice is finalized and updated to be an open invoice.
@@ -190,13 +193,15 @@ const (
 	// Invoice is marked uncollectible.
 	EventInvoiceMarkedUncollectible = "invoice.marked_uncollectible"
 
-	// Invoice payment attempt succeeds or an invoice is marked as paid out-of-band.
+	// Invoice payment attempt succeeds or an invoice is marked as paid
+	// out-of-band.
 	EventInvoicePaid = "invoice.paid"
 
 	// Invoice payment attempt requires further user action to complete.
 	EventInvoicePaymentActionRequired = "invoice.payment_action_required"
 
-	// Invoice payment attempt fails, due either to a declined payment or to the lack of a stored payment method.
+	// Invoice payment attempt fails, due either to a declined payment or to the
+	// lack of a stored payment method.
 	EventInvoicePaymentFailed = "invoice.payment_failed"
 
 	// Invoice payment attempt succeeds.
@@ -206,7 +211,7 @@ const (
 	EventInvoiceSent = "invoice.sent"
 
 	// X number of days before a subscription is scheduled to create an invoice
-	// that is automatically charged&mdash;where X is determined by your
+	// that is automatically charged — where X is determined by your
 	// subscriptions settings. Note: The received Invoice object will not have
 	// an invoice ID.
 	EventInvoiceUpcoming = "invoice.upcoming"
@@ -300,7 +305,8 @@ const (
 	// New PaymentIntent is created.
 	EventPaymentIntentCreated = "payment_intent.created"
 
-	// PaymentIntent has failed the attempt to create a payment method or a payment.
+	// PaymentIntent has failed the attempt to create a payment method or a
+	// payment.
 	EventPaymentIntentPaymentFailed = "payment_intent.payment_failed"
 
 	// PaymentIntent has started processing.
@@ -403,7 +409,8 @@ const (
 	// Requested ReportRun completed succesfully.
 	EventReportingReportRunSucceeded = "reporting.report_run.succeeded"
 
-	// ReportType is updated (typically to indicate that a new day's data has come available).
+	// ReportType is updated (typically to indicate that a new day's data has
+	// come available).
 	EventReportingReportTypeUpdated = "reporting.report_type.updated"
 
 	// Review is closed. The review's reason field indicates why: approved,
@@ -452,7 +459,8 @@ const (
 	// Source mandate notification method is set to manual.
 	EventSourceMandateNotification = "source.mandate_notification"
 
-	// Refund attributes are required on a receiver source to process a refund or a mispayment.
+	// Refund attributes are required on a receiver source to process a refund
+	// or a mispayment.
 	EventSourceRefundAttributesRequired = "source.refund_attributes_required"
 
 	// Source transaction is created.
@@ -520,3 +528,339 @@ const (
 	// Transfer's description or metadata is updated.
 	EventTransferUpdated = "transfer.updated"
 )
+
+// EventTypes is a list of all known webhook event types.
+var EventTypes = []EventType{
+	{Type: EventAccountUpdated, Resource: "account", Action: "updated", Object: "account", Connect: true,
+		Description: "Account status or property has changed."},
+	{Type: EventAccountApplicationAuthorized, Resource: "account.application", Action: "authorized", Object: "application", Connect: true,
+		Description: "User authorizes an application. Sent to the related application only."},
+	{Type: EventAccountApplicationDeauthorized, Resource: "account.application", Action: "deauthorized", Object: "application", Connect: true,
+		Description: "User deauthorizes an application. Sent to the related application only."},
+	{Type: EventAccountExternalAccountCreated, Resource: "account.external_account", Action: "created", Object: "bank_account", Connect: true,
+		Description: "External account is created."},
+	{Type: EventAccountExternalAccountDeleted, Resource: "account.external_account", Action: "deleted", Object: "bank_account", Connect: true,
+		Description: "External account is deleted."},
+	{Type: EventAccountExternalAccountUpdated, Resource: "account.external_account", Action: "updated", Object: "bank_account", Connect: true,
+		Description: "External account is updated."},
+	{Type: EventApplicationFeeCreated, Resource: "application_fee", Action: "created", Object: "application_fee", Connect: true,
+		Description: "Application fee is created on a charge."},
+	{Type: EventApplicationFeeRefunded, Resource: "application_fee", Action: "refunded", Object: "application_fee", Connect: true,
+		Description: "Application fee is refunded, whether from refunding a charge or from refunding the application fee directly. This includes partial refunds."},
+	{Type: EventApplicationFeeRefundUpdated, Resource: "application_fee.refund", Action: "updated", Object: "fee_refund", Connect: true,
+		Description: "Application fee refund is updated."},
+	{Type: EventBalanceAvailable, Resource: "balance", Action: "available", Object: "balance",
+		Description: "Your Stripe balance has been updated (e.g., when a charge is available to be paid out). By default, Stripe automatically transfers funds in your balance to your bank account on a daily basis."},
+	{Type: EventBillingPortalConfigurationCreated, Resource: "billing_portal.configuration", Action: "created", Object: "billing_portal.configuration",
+		Description: "Portal configuration is created."},
+	{Type: EventBillingPortalConfigurationUpdated, Resource: "billing_portal.configuration", Action: "updated", Object: "billing_portal.configuration",
+		Description: "Portal configuration is updated."},
+	{Type: EventCapabilityUpdated, Resource: "capability", Action: "updated", Object: "capability", Connect: true,
+		Description: "Capability has new requirements or a new status."},
+	{Type: EventChargeCaptured, Resource: "charge", Action: "captured", Object: "charge",
+		Description: "Previously uncaptured charge is captured."},
+	{Type: EventChargeExpired, Resource: "charge", Action: "expired", Object: "charge",
+		Description: "Uncaptured charge expires."},
+	{Type: EventChargeFailed, Resource: "charge", Action: "failed", Object: "charge",
+		Description: "Failed charge attempt occurs."},
+	{Type: EventChargePending, Resource: "charge", Action: "pending", Object: "charge",
+		Description: "Pending charge is created."},
+	{Type: EventChargeRefunded, Resource: "charge", Action: "refunded", Object: "charge",
+		Description: "Charge is refunded, including partial refunds."},
+	{Type: EventChargeSucceeded, Resource: "charge", Action: "succeeded", Object: "charge",
+		Description: "New charge is created and is successful."},
+	{Type: EventChargeUpdated, Resource: "charge", Action: "updated", Object: "charge",
+		Description: "Charge description or metadata is updated."},
+	{Type: EventChargeDisputeClosed, Resource: "charge.dispute", Action: "closed", Object: "dispute",
+		Description: "Dispute is closed and the dispute status changes to lost, warning_closed, or won."},
+	{Type: EventChargeDisputeCreated, Resource: "charge.dispute", Action: "created", Object: "dispute",
+		Description: "Customer disputes a charge with their bank."},
+	{Type: EventChargeDisputeFundsReinstated, Resource: "charge.dispute", Action: "funds_reinstated", Object: "dispute",
+		Description: "Funds are reinstated to your account after a dispute is closed. This includes partially refunded payments."},
+	{Type: EventChargeDisputeFundsWithdrawn, Resource: "charge.dispute", Action: "funds_withdrawn", Object: "dispute",
+		Description: "Funds are removed from your account due to a dispute."},
+	{Type: EventChargeDisputeUpdated, Resource: "charge.dispute", Action: "updated", Object: "dispute",
+		Description: "Dispute is updated (usually with evidence)."},
+	{Type: EventChargeRefundUpdated, Resource: "charge.refund", Action: "updated", Object: "refund",
+		Description: "Refund is updated, on selected payment methods."},
+	{Type: EventCheckoutSessionAsyncPaymentFailed, Resource: "checkout.session", Action: "async_payment_failed", Object: "checkout.session",
+		Description: "Payment intent using a delayed payment method fails."},
+	{Type: EventCheckoutSessionAsyncPaymentSucceeded, Resource: "checkout.session", Action: "async_payment_succeeded", Object: "checkout.session",
+		Description: "Payment intent using a delayed payment method finally succeeds."},
+	{Type: EventCheckoutSessionCompleted, Resource: "checkout.session", Action: "completed", Object: "checkout.session",
+		Description: "Checkout Session has been successfully completed."},
+	{Type: EventCouponCreated, Resource: "coupon", Action: "created", Object: "coupon",
+		Description: "Coupon is created."},
+	{Type: EventCouponDeleted, Resource: "coupon", Action: "deleted", Object: "coupon",
+		Description: "Coupon is deleted."},
+	{Type: EventCouponUpdated, Resource: "coupon", Action: "updated", Object: "coupon",
+		Description: "Coupon is updated."},
+	{Type: EventCreditNoteCreated, Resource: "credit_note", Action: "created", Object: "credit_note",
+		Description: "Credit note is created."},
+	{Type: EventCreditNoteUpdated, Resource: "credit_note", Action: "updated", Object: "credit_note",
+		Description: "Credit note is updated."},
+	{Type: EventCreditNoteVoided, Resource: "credit_note", Action: "voided", Object: "credit_note",
+		Description: "Credit note is voided."},
+	{Type: EventCustomerCreated, Resource: "customer", Action: "created", Object: "customer",
+		Description: "New customer is created."},
+	{Type: EventCustomerDeleted, Resource: "customer", Action: "deleted", Object: "customer",
+		Description: "Customer is deleted."},
+	{Type: EventCustomerUpdated, Resource: "customer", Action: "updated", Object: "customer",
+		Description: "Any property of a customer changes."},
+	{Type: EventCustomerDiscountCreated, Resource: "customer.discount", Action: "created", Object: "discount",
+		Description: "Coupon is attached to a customer."},
+	{Type: EventCustomerDiscountDeleted, Resource: "customer.discount", Action: "deleted", Object: "discount",
+		Description: "Coupon is removed from a customer."},
+	{Type: EventCustomerDiscountUpdated, Resource: "customer.discount", Action: "updated", Object: "discount",
+		Description: "Customer is switched from one coupon to another."},
+	{Type: EventCustomerSourceCreated, Resource: "customer.source", Action: "created", Object: "source",
+		Description: "New source is created for a customer."},
+	{Type: EventCustomerSourceDeleted, Resource: "customer.source", Action: "deleted", Object: "source",
+		Description: "Source is removed from a customer."},
+	{Type: EventCustomerSourceExpiring, Resource: "customer.source", Action: "expiring", Object: "source",
+		Description: "Card or source will expire at the end of the month."},
+	{Type: EventCustomerSourceUpdated, Resource: "customer.source", Action: "updated", Object: "source",
+		Description: "Source's details are changed."},
+	{Type: EventCustomerSubscriptionCreated, Resource: "customer.subscription", Action: "created", Object: "subscription",
+		Description: "Customer is signed up for a new plan."},
+	{Type: EventCustomerSubscriptionDeleted, Resource: "customer.subscription", Action: "deleted", Object: "subscription",
+		Description: "Customer's subscription ends."},
+	{Type: EventCustomerSubscriptionPendingUpdateApplied, Resource: "customer.subscription", Action: "pending_update_applied", Object: "subscription",
+		Description: "Customer's subscription's pending update is applied, and the subscription is updated."},
+	{Type: EventCustomerSubscriptionPendingUpdateExpired, Resource: "customer.subscription", Action: "pending_update_expired", Object: "subscription",
+		Description: "Customer's subscription's pending update expires before the related invoice is paid."},
+	{Type: EventCustomerSubscriptionTrialWillEnd, Resource: "customer.subscription", Action: "trial_will_end", Object: "subscription",
+		Description: "Three days before a subscription's trial period is scheduled to end, or when a trial is ended immediately (using trial_end=now)."},
+	{Type: EventCustomerSubscriptionUpdated, Resource: "customer.subscription", Action: "updated", Object: "subscription",
+		Description: "Subscription changes (e.g., switching from one plan to another, or changing the status from trial to active)."},
+	{Type: EventCustomerTaxIdCreated, Resource: "customer.tax_id", Action: "created", Object: "tax_id",
+		Description: "Tax ID is created for a customer."},
+	{Type: EventCustomerTaxIdDeleted, Resource: "customer.tax_id", Action: "deleted", Object: "tax_id",
+		Description: "Tax ID is deleted from a customer."},
+	{Type: EventCustomerTaxIdUpdated, Resource: "customer.tax_id", Action: "updated", Object: "tax_id",
+		Description: "Customer's tax ID is updated."},
+	{Type: EventFileCreated, Resource: "file", Action: "created", Object: "file",
+		Description: "New Stripe-generated file is available for your account."},
+	{Type: EventInvoiceCreated, Resource: "invoice", Action: "created", Object: "invoice",
+		Description: "New invoice is created. To learn how webhooks can be used with this event, and how they can affect it, see Using Webhooks with Subscriptions."},
+	{Type: EventInvoiceDeleted, Resource: "invoice", Action: "deleted", Object: "invoice",
+		Description: "Draft invoice is deleted."},
+	{Type: EventInvoiceFinalizationFailed, Resource: "invoice", Action: "finalization_failed", Object: "invoice",
+		Description: "Draft invoice cannot be finalized. See the invoice’s last finalization error for details."},
+	{Type: EventInvoiceFinalized, Resource: "invoice", Action: "finalized", Object: "invoice",
+		Description: "Draft invoice is finalized and updated to be an open invoice."},
+	{Type: EventInvoiceMarkedUncollectible, Resource: "invoice", Action: "marked_uncollectible", Object: "invoice",
+		Description: "Invoice is marked uncollectible."},
+	{Type: EventInvoicePaid, Resource: "invoice", Action: "paid", Object: "invoice",
+		Description: "Invoice payment attempt succeeds or an invoice is marked as paid out-of-band."},
+	{Type: EventInvoicePaymentActionRequired, Resource: "invoice", Action: "payment_action_required", Object: "invoice",
+		Description: "Invoice payment attempt requires further user action to complete."},
+	{Type: EventInvoicePaymentFailed, Resource: "invoice", Action: "payment_failed", Object: "invoice",
+		Description: "Invoice payment attempt fails, due either to a declined payment or to the lack of a stored payment method."},
+	{Type: EventInvoicePaymentSucceeded, Resource: "invoice", Action: "payment_succeeded", Object: "invoice",
+		Description: "Invoice payment attempt succeeds."},
+	{Type: EventInvoiceSent, Resource: "invoice", Action: "sent", Object: "invoice",
+		Description: "Invoice email is sent out."},
+	{Type: EventInvoiceUpcoming, Resource: "invoice", Action: "upcoming", Object: "invoice",
+		Description: "X number of days before a subscription is scheduled to create an invoice that is automatically charged — where X is determined by your subscriptions settings. Note: The received Invoice object will not have an invoice ID."},
+	{Type: EventInvoiceUpdated, Resource: "invoice", Action: "updated", Object: "invoice",
+		Description: "Invoice changes (e.g., the invoice amount)."},
+	{Type: EventInvoiceVoided, Resource: "invoice", Action: "voided", Object: "invoice",
+		Description: "Invoice is voided."},
+	{Type: EventInvoiceitemCreated, Resource: "invoiceitem", Action: "created", Object: "invoiceitem",
+		Description: "Invoice item is created."},
+	{Type: EventInvoiceitemDeleted, Resource: "invoiceitem", Action: "deleted", Object: "invoiceitem",
+		Description: "Invoice item is deleted."},
+	{Type: EventInvoiceitemUpdated, Resource: "invoiceitem", Action: "updated", Object: "invoiceitem",
+		Description: "Invoice item is updated."},
+	{Type: EventIssuingAuthorizationCreated, Resource: "issuing_authorization", Action: "created", Object: "issuing.authorization",
+		Description: "Authorization is created."},
+	{Type: EventIssuingAuthorizationRequest, Resource: "issuing_authorization", Action: "request", Object: "issuing.authorization",
+		Description: "Represents a synchronous request for authorization, see Using your integration to handle authorization requests."},
+	{Type: EventIssuingAuthorizationUpdated, Resource: "issuing_authorization", Action: "updated", Object: "issuing.authorization",
+		Description: "Authorization is updated."},
+	{Type: EventIssuingCardCreated, Resource: "issuing_card", Action: "created", Object: "issuing.card",
+		Description: "Card is created."},
+	{Type: EventIssuingCardUpdated, Resource: "issuing_card", Action: "updated", Object: "issuing.card",
+		Description: "Card is updated."},
+	{Type: EventIssuingCardholderCreated, Resource: "issuing_cardholder", Action: "created", Object: "issuing.cardholder",
+		Description: "Cardholder is created."},
+	{Type: EventIssuingCardholderUpdated, Resource: "issuing_cardholder", Action: "updated", Object: "issuing.cardholder",
+		Description: "Cardholder is updated."},
+	{Type: EventIssuingDisputeClosed, Resource: "issuing_dispute", Action: "closed", Object: "issuing.dispute",
+		Description: "Dispute is won, lost or expired."},
+	{Type: EventIssuingDisputeCreated, Resource: "issuing_dispute", Action: "created", Object: "issuing.dispute",
+		Description: "Dispute is created."},
+	{Type: EventIssuingDisputeFundsReinstated, Resource: "issuing_dispute", Action: "funds_reinstated", Object: "issuing.dispute",
+		Description: "Funds are reinstated to your account for an Issuing dispute."},
+	{Type: EventIssuingDisputeSubmitted, Resource: "issuing_dispute", Action: "submitted", Object: "issuing.dispute",
+		Description: "Dispute is submitted."},
+	{Type: EventIssuingDisputeUpdated, Resource: "issuing_dispute", Action: "updated", Object: "issuing.dispute",
+		Description: "Dispute is updated."},
+	{Type: EventIssuingTransactionCreated, Resource: "issuing_transaction", Action: "created", Object: "issuing.transaction",
+		Description: "Issuing transaction is created."},
+	{Type: EventIssuingTransactionUpdated, Resource: "issuing_transaction", Action: "updated", Object: "issuing.transaction",
+		Description: "Issuing transaction is updated."},
+	{Type: EventMandateUpdated, Resource: "mandate", Action: "updated", Object: "mandate",
+		Description: "Mandate is updated."},
+	{Type: EventOrderCreated, Resource: "order", Action: "created", Object: "order", Deprecated: true,
+		Description: "Order is created."},
+	{Type: EventOrderPaymentFailed, Resource: "order", Action: "payment_failed", Object: "order", Deprecated: true,
+		Description: "Order payment attempt fails."},
+	{Type: EventOrderPaymentSucceeded, Resource: "order", Action: "payment_succeeded", Object: "order", Deprecated: true,
+		Description: "Order payment attempt succeeds."},
+	{Type: EventOrderUpdated, Resource: "order", Action: "updated", Object: "order", Deprecated: true,
+		Description: "Order is updated."},
+	{Type: EventOrderReturnCreated, Resource: "order_return", Action: "created", Object: "order_return", Deprecated: true,
+		Description: "Order return is created."},
+	{Type: EventPaymentIntentAmountCapturableUpdated, Resource: "payment_intent", Action: "amount_capturable_updated", Object: "payment_intent",
+		Description: "PaymentIntent has funds to be captured. Check the amount_capturable property on the PaymentIntent to determine the amount that can be captured. You may capture the PaymentIntent with an amount_to_capture value up to the specified amount. Learn more about capturing PaymentIntents."},
+	{Type: EventPaymentIntentCanceled, Resource: "payment_intent", Action: "canceled", Object: "payment_intent",
+		Description: "PaymentIntent is canceled."},
+	{Type: EventPaymentIntentCreated, Resource: "payment_intent", Action: "created", Object: "payment_intent",
+		Description: "New PaymentIntent is created."},
+	{Type: EventPaymentIntentPaymentFailed, Resource: "payment_intent", Action: "payment_failed", Object: "payment_intent",
+		Description: "PaymentIntent has failed the attempt to create a payment method or a payment."},
+	{Type: EventPaymentIntentProcessing, Resource: "payment_intent", Action: "processing", Object: "payment_intent",
+		Description: "PaymentIntent has started processing."},
+	{Type: EventPaymentIntentRequiresAction, Resource: "payment_intent", Action: "requires_action", Object: "payment_intent",
+		Description: "PaymentIntent transitions to requires_action state"},
+	{Type: EventPaymentIntentSucceeded, Resource: "payment_intent", Action: "succeeded", Object: "payment_intent",
+		Description: "PaymentIntent has successfully completed payment."},
+	{Type: EventPaymentMethodAttached, Resource: "payment_method", Action: "attached", Object: "payment_method",
+		Description: "New payment method is attached to a customer."},
+	{Type: EventPaymentMethodAutomaticallyUpdated, Resource: "payment_method", Action: "automatically_updated", Object: "payment_method",
+		Description: "Payment method's details are automatically updated by the network."},
+	{Type: EventPaymentMethodDetached, Resource: "payment_method", Action: "detached", Object: "payment_method",
+		Description: "Payment method is detached from a customer."},
+	{Type: EventPaymentMethodUpdated, Resource: "payment_method", Action: "updated", Object: "payment_method",
+		Description: "Payment method is updated via the PaymentMethod update API."},
+	{Type: EventPayoutCanceled, Resource: "payout", Action: "canceled", Object: "payout",
+		Description: "Payout is canceled."},
+	{Type: EventPayoutCreated, Resource: "payout", Action: "created", Object: "payout",
+		Description: "Payout is created."},
+	{Type: EventPayoutFailed, Resource: "payout", Action: "failed", Object: "payout",
+		Description: "Payout attempt fails."},
+	{Type: EventPayoutPaid, Resource: "payout", Action: "paid", Object: "payout",
+		Description: "Payout is expected to be available in the destination account. If the payout fails, a payout.failed notification is also sent, at a later time."},
+	{Type: EventPayoutUpdated, Resource: "payout", Action: "updated", Object: "payout",
+		Description: "Payout is updated."},
+	{Type: EventPersonCreated, Resource: "person", Action: "created", Object: "person", Connect: true,
+		Description: "Person associated with an account is created."},
+	{Type: EventPersonDeleted, Resource: "person", Action: "deleted", Object: "person", Connect: true,
+		Description: "Person associated with an account is deleted."},
+	{Type: EventPersonUpdated, Resource: "person", Action: "updated", Object: "person", Connect: true,
+		Description: "Person associated with an account is updated."},
+	{Type: EventPlanCreated, Resource: "plan", Action: "created", Object: "plan",
+		Description: "Plan is created."},
+	{Type: EventPlanDeleted, Resource: "plan", Action: "deleted", Object: "plan",
+		Description: "Plan is deleted."},
+	{Type: EventPlanUpdated, Resource: "plan", Action: "updated", Object: "plan",
+		Description: "Plan is updated."},
+	{Type: EventPriceCreated, Resource: "price", Action: "created", Object: "price",
+		Description: "Price is created."},
+	{Type: EventPriceDeleted, Resource: "price", Action: "deleted", Object: "price",
+		Description: "Price is deleted."},
+	{Type: EventPriceUpdated, Resource: "price", Action: "updated", Object: "price",
+		Description: "Price is updated."},
+	{Type: EventProductCreated, Resource: "product", Action: "created", Object: "product",
+		Description: "Product is created."},
+	{Type: EventProductDeleted, Resource: "product", Action: "deleted", Object: "product",
+		Description: "Product is deleted."},
+	{Type: EventProductUpdated, Resource: "product", Action: "updated", Object: "product",
+		Description: "Product is updated."},
+	{Type: EventPromotionCodeCreated, Resource: "promotion_code", Action: "created", Object: "promotion_code",
+		Description: "Promotion code is created."},
+	{Type: EventPromotionCodeUpdated, Resource: "promotion_code", Action: "updated", Object: "promotion_code",
+		Description: "Promotion code is updated."},
+	{Type: EventRadarEarlyFraudWarningCreated, Resource: "radar.early_fraud_warning", Action: "created", Object: "radar.early_fraud_warning",
+		Description: "Early fraud warning is created."},
+	{Type: EventRadarEarlyFraudWarningUpdated, Resource: "radar.early_fraud_warning", Action: "updated", Object: "radar.early_fraud_warning",
+		Description: "Early fraud warning is updated."},
+	{Type: EventRecipientCreated, Resource: "recipient", Action: "created", Object: "recipient", Deprecated: true,
+		Description: "Recipient is created."},
+	{Type: EventRecipientDeleted, Resource: "recipient", Action: "deleted", Object: "recipient", Deprecated: true,
+		Description: "Recipient is deleted."},
+	{Type: EventRecipientUpdated, Resource: "recipient", Action: "updated", Object: "recipient", Deprecated: true,
+		Description: "Recipient is updated."},
+	{Type: EventReportingReportRunFailed, Resource: "reporting.report_run", Action: "failed", Object: "reporting.report_run",
+		Description: "Requested ReportRun failed to complete."},
+	{Type: EventReportingReportRunSucceeded, Resource: "reporting.report_run", Action: "succeeded", Object: "reporting.report_run",
+		Description: "Requested ReportRun completed succesfully."},
+	{Type: EventReportingReportTypeUpdated, Resource: "reporting.report_type", Action: "updated", Object: "reporting.report_type",
+		Description: "ReportType is updated (typically to indicate that a new day's data has come available)."},
+	{Type: EventReviewClosed, Resource: "review", Action: "closed", Object: "review",
+		Description: "Review is closed. The review's reason field indicates why: approved, disputed, refunded, or refunded_as_fraud."},
+	{Type: EventReviewOpened, Resource: "review", Action: "opened", Object: "review",
+		Description: "Review is opened."},
+	{Type: EventSetupIntentCanceled, Resource: "setup_intent", Action: "canceled", Object: "setup_intent",
+		Description: "SetupIntent is canceled."},
+	{Type: EventSetupIntentCreated, Resource: "setup_intent", Action: "created", Object: "setup_intent",
+		Description: "New SetupIntent is created."},
+	{Type: EventSetupIntentRequiresAction, Resource: "setup_intent", Action: "requires_action", Object: "setup_intent",
+		Description: "SetupIntent is in requires_action state."},
+	{Type: EventSetupIntentSetupFailed, Resource: "setup_intent", Action: "setup_failed", Object: "setup_intent",
+		Description: "SetupIntent has failed the attempt to setup a payment method."},
+	{Type: EventSetupIntentSucceeded, Resource: "setup_intent", Action: "succeeded", Object: "setup_intent",
+		Description: "SetupIntent has successfully setup a payment method."},
+	{Type: EventSigmaScheduledQueryRunCreated, Resource: "sigma.scheduled_query_run", Action: "created", Object: "scheduled_query_run",
+		Description: "Sigma scheduled query run finishes."},
+	{Type: EventSkuCreated, Resource: "sku", Action: "created", Object: "sku", Deprecated: true,
+		Description: "SKU is created."},
+	{Type: EventSkuDeleted, Resource: "sku", Action: "deleted", Object: "sku", Deprecated: true,
+		Description: "SKU is deleted."},
+	{Type: EventSkuUpdated, Resource: "sku", Action: "updated", Object: "sku", Deprecated: true,
+		Description: "SKU is updated."},
+	{Type: EventSourceCanceled, Resource: "source", Action: "canceled", Object: "source",
+		Description: "Source is canceled."},
+	{Type: EventSourceChargeable, Resource: "source", Action: "chargeable", Object: "source",
+		Description: "Source transitions to chargeable."},
+	{Type: EventSourceFailed, Resource: "source", Action: "failed", Object: "source",
+		Description: "Source fails."},
+	{Type: EventSourceMandateNotification, Resource: "source", Action: "mandate_notification", Object: "source_mandate_notification",
+		Description: "Source mandate notification method is set to manual."},
+	{Type: EventSourceRefundAttributesRequired, Resource: "source", Action: "refund_attributes_required", Object: "source",
+		Description: "Refund attributes are required on a receiver source to process a refund or a mispayment."},
+	{Type: EventSourceTransactionCreated, Resource: "source.transaction", Action: "created", Object: "source_transaction",
+		Description: "Source transaction is created."},
+	{Type: EventSourceTransactionUpdated, Resource: "source.transaction", Action: "updated", Object: "source_transaction",
+		Description: "Source transaction is updated."},
+	{Type: EventSubscriptionScheduleAborted, Resource: "subscription_schedule", Action: "aborted", Object: "subscription_schedule",
+		Description: "Subscription schedule is canceled due to the underlying subscription being canceled because of delinquency."},
+	{Type: EventSubscriptionScheduleCanceled, Resource: "subscription_schedule", Action: "canceled", Object: "subscription_schedule",
+		Description: "Subscription schedule is canceled."},
+	{Type: EventSubscriptionScheduleCompleted, Resource: "subscription_schedule", Action: "completed", Object: "subscription_schedule",
+		Description: "New subscription schedule is completed."},
+	{Type: EventSubscriptionScheduleCreated, Resource: "subscription_schedule", Action: "created", Object: "subscription_schedule",
+		Description: "New subscription schedule is created."},
+	{Type: EventSubscriptionScheduleExpiring, Resource: "subscription_schedule", Action: "expiring", Object: "subscription_schedule",
+		Description: "7 days before a subscription schedule will expire."},
+	{Type: EventSubscriptionScheduleReleased, Resource: "subscription_schedule", Action: "released", Object: "subscription_schedule",
+		Description: "New subscription schedule is released."},
+	{Type: EventSubscriptionScheduleUpdated, Resource: "subscription_schedule", Action: "updated", Object: "subscription_schedule",
+		Description: "Subscription schedule is updated."},
+	{Type: EventTaxRateCreated, Resource: "tax_rate", Action: "created", Object: "tax_rate",
+		Description: "New tax rate is created."},
+	{Type: EventTaxRateUpdated, Resource: "tax_rate", Action: "updated", Object: "tax_rate",
+		Description: "Tax rate is updated."},
+	{Type: EventTopupCanceled, Resource: "topup", Action: "canceled", Object: "topup",
+		Description: "Top-up is canceled."},
+	{Type: EventTopupCreated, Resource: "topup", Action: "created", Object: "topup",
+		Description: "Top-up is created."},
+	{Type: EventTopupFailed, Resource: "topup", Action: "failed", Object: "topup",
+		Description: "Top-up fails."},
+	{Type: EventTopupReversed, Resource: "topup", Action: "reversed", Object: "topup",
+		Description: "Top-up is reversed."},
+	{Type: EventTopupSucceeded, Resource: "topup", Action: "succeeded", Object: "topup",
+		Description: "Top-up succeeds."},
+	{Type: EventTransferCreated, Resource: "transfer", Action: "created", Object: "transfer",
+		Description: "Transfer is created."},
+	{Type: EventTransferFailed, Resource: "transfer", Action: "failed", Object: "transfer",
+		Description: "Transfer failed."},
+	{Type: EventTransferPaid, Resource: "transfer", Action: "paid", Object: "transfer",
+		Description: "After a transfer is paid. For Instant Payouts, the event will typically be sent within 30 minutes."},
+	{Type: EventTransferReversed, Resource: "transfer", Action: "reversed", Object: "transfer",
+		Description: "Transfer is reversed, including partial reversals."},
+	{Type: EventTransferUpdated, Resource: "transfer", Action: "updated", Object: "transfer",
+		Description: "Transfer's description or metadata is updated."},
+}
@@ -0,0 +1,54 @@
+package zstripe
+
+import "sync"
+
+//go:generate go run ./internal/gen -spec $STRIPE_OPENAPI
+
+// EventType describes a webhook event type.
+type EventType struct {
+	Type        string // Event type, e.g. "invoice.payment_failed".
+	Resource    string // Resource, e.g. "invoice" or "customer.subscription".
+	Action      string // Action, e.g. "payment_failed".
+	Object      string // Object type in data.object, e.g. "invoice".
+	Description string // Human-readable description.
+	Connect     bool   // Only sent to Connect platforms.
+	Deprecated  bool   // Resource is deprecated.
+}
+
+var (
+	eventTypesOnce sync.Once
+	eventTypes     map[string]EventType
+)
+
+// LookupEvent gets the EventType for an event type such as
+// "invoice.payment_failed".
+func LookupEvent(typ string) (EventType, bool) {
+	eventTypesOnce.Do(func() {
+		eventTypes = make(map[string]EventType, len(EventTypes))
+		for _, t := range EventTypes {
+			eventTypes[t.Type] = t
+		}
+	})
+	t, ok := eventTypes[typ]
+	return t, ok
+}
+
+// KnownEvent reports if the event type is in EventTypes.
+//
+// Unknown types are usually events added in newer API versions.
+func KnownEvent(typ string) bool {
+	_, ok := LookupEvent(typ)
+	return ok
+}
+
+// EventsFor gets all event types for a resource, such as "invoice" or
+// "customer.subscription".
+func EventsFor(resource string) []EventType {
+	var r []EventType
+	for _, t := range EventTypes {
+		if t.Resource == resource {
+			r = append(r, t)
+		}
+	}
+	return r
+}
@@ -0,0 +1,54 @@
+package zstripe
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventTypes(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, et := range EventTypes {
+		if seen[et.Type] {
+			t.Errorf("duplicate: %q", et.Type)
+		}
+		seen[et.Type] = true
+		if et.Resource+"."+et.Action != et.Type {
+			t.Errorf("%q: resource=%q action=%q", et.Type, et.Resource, et.Action)
+		}
+		if et.Object == "" || et.Description == "" {
+			t.Errorf("%q: no object or description", et.Type)
+		}
+	}
+
+	et, ok := LookupEvent(EventInvoicePaymentFailed)
+	if !ok || et.Resource != "invoice" || et.Action != "payment_failed" || et.Object != "invoice" {
+		t.Errorf("%#v", et)
+	}
+	if et, _ := LookupEvent(EventSkuCreated); !et.Deprecated {
+		t.Error("sku.created not deprecated")
+	}
+	if KnownEvent("invoice.will_be_due") {
+		t.Error("invoice.will_be_due is known")
+	}
+	if got := len(EventsFor("customer.subscription")); got != 6 {
+		t.Errorf("EventsFor: %d", got)
+	}
+}
+
+func TestUnknownEvent(t *testing.T) {
+	var unknown []string
+	SignSecret, UnknownEvent = "testing", func(e Event) { unknown = append(unknown, e.Type) }
+	defer func() { SignSecret, UnknownEvent = "", nil }()
+
+	for _, typ := range []string{EventInvoicePaid, "invoice.will_be_due"} {
+		var e Event
+		err := e.Read(httptest.NewRequest("POST", "/", strings.NewReader(`{"type": "`+typ+`"}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(unknown) != 1 || unknown[0] != "invoice.will_be_due" {
+		t.Errorf("%v", unknown)
+	}
+}
@@ -0,0 +1,86 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrEventMismatch is used when the event retrieved from the API doesn't match
+// the event that was sent to the webhook.
+var ErrEventMismatch = errors.New("zstripe.Event.Fetch: event doesn't match the API")
+
+// Fetch replaces the event with the authoritative version from the API.
+//
+// This retrieves /v1/events/{id} with the SecretKey and verifies that the
+// Livemode and Account match. This is useful as an additional check for
+// high-value events such as payout.paid, on top of the signature verification
+// in Read.
+func (e *Event) Fetch() error {
+	if !strings.HasPrefix(e.ID, "evt_") {
+		return fmt.Errorf("zstripe.Event.Fetch: invalid event ID %q", e.ID)
+	}
+
+	var h http.Header
+	if e.Account != "" {
+		h = http.Header{"Stripe-Account": {e.Account}}
+	}
+
+	var f Event
+	_, err := RequestWith(&f, "GET", "/v1/events/"+url.PathEscape(e.ID), "", h)
+	if err != nil {
+		return fmt.Errorf("zstripe.Event.Fetch: %w", err)
+	}
+
+	if f.ID != e.ID || f.Livemode != e.Livemode || (f.Account != "" && f.Account != e.Account) {
+		return fmt.Errorf("%w: livemode=%t account=%q; API has livemode=%t account=%q",
+			ErrEventMismatch, e.Livemode, e.Account, f.Livemode, f.Account)
+	}
+
+	if f.Account == "" {
+		f.Account = e.Account
+	}
+	*e = f
+	return nil
+}
+
+// ReadFetch reads the event from the request body and retrieves it from the
+// API with Fetch, ignoring everything in the payload except the ID, Livemode,
+// and Account.
+//
+// The signature is validated if SignSecret is set, but it doesn't need to be:
+// the event is retrieved with the secret key, so it's safe to use without a
+// signing secret.
+func (e *Event) ReadFetch(r *http.Request) error {
+	if SignSecret != "" {
+		err := e.Read(r)
+		if err != nil {
+			return err
+		}
+		return e.Fetch()
+	}
+
+	b, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	var p struct {
+		ID       string `json:"id"`
+		Livemode bool   `json:"livemode"`
+		Account  string `json:"account"`
+	}
+	err = json.Unmarshal(b, &p)
+	if err != nil {
+		return fmt.Errorf("zstripe.Event.ReadFetch: %w", err)
+	}
+
+	*e = Event{ID: p.ID, Livemode: p.Livemode, Account: p.Account}
+	err = e.Fetch()
+	if err != nil {
+		return err
+	}
+	return Verifier{}.Check(e.Livemode, e.Account)
+}
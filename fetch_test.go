@@ -0,0 +1,58 @@
+package zstripe
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadFetch(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/events/evt_1":
+			if r.Header.Get("Stripe-Account") != "acct_1" {
+				w.WriteHeader(404)
+				return
+			}
+			fmt.Fprintln(w, `{"id": "evt_1", "type": "payout.paid", "livemode": false, "account": "acct_1"}`)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer api.Close()
+	API = api.URL
+	SecretKey = "sk_test_xxx"
+
+	tests := []struct {
+		body, wantType, wantErr string
+	}{
+		{`{"id": "evt_1", "type": "forged", "account": "acct_1"}`, EventPayoutPaid, ""},
+		{`{"id": "evt_1", "type": "forged", "account": "acct_1", "livemode": true}`, "", "doesn't match"},
+		{`{"id": "evt_1", "type": "forged", "account": "acct_2"}`, "", "404"},
+		{`{"id": "../customers/cus_1"}`, "", "invalid event ID"},
+		{`{"id": "evt_1", "padding": "` + strings.Repeat("x", int(MaxBodySize)) + `"}`, "", "larger than MaxBodySize"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			var e Event
+			err := e.ReadFetch(r)
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error:\nwant: %s\ngot:  %s", tt.wantErr, err)
+			}
+			if e.Type != tt.wantType && tt.wantErr == "" {
+				t.Errorf("type: %q", e.Type)
+			}
+			if tt.wantErr == "doesn't match" && !errors.Is(err, ErrEventMismatch) {
+				t.Errorf("not ErrEventMismatch")
+			}
+			if tt.wantErr == "larger than MaxBodySize" && !errors.Is(err, ErrWebhookTooLarge) {
+				t.Errorf("not ErrWebhookTooLarge")
+			}
+		})
+	}
+}
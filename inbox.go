@@ -0,0 +1,358 @@
+package zstripe
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Possible inbox statuses.
+const (
+	InboxPending    = "pending"
+	InboxProcessing = "processing"
+	InboxDone       = "done"
+	InboxDead       = "dead"
+)
+
+// Inbox stores webhook events in an SQL table before acknowledging them, so
+// they're not lost if the process crashes.
+//
+// The table must exist; see Schema(). Use Process() or Run() to process the
+// stored events.
+type Inbox struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string // Table name; defaults to "zstripe_inbox".
+
+	Handler EventHandler
+
+	// Number of attempts before an event is marked as InboxDead, and the delay
+	// before the first retry; the delay is doubled on every retry, up to a
+	// maximum of 24 hours. Defaults to 10 and 30 seconds.
+	//
+	// Attempts that time out count as well, so events that crash the process
+	// aren't retried forever.
+	MaxAttempts int
+	Backoff     time.Duration
+
+	// Events that have been processing for longer than this are assumed to
+	// have failed (e.g. because the process crashed). Defaults to 5 minutes.
+	Timeout time.Duration
+
+	// Called for errors in Run(); defaults to printing to stderr.
+	Error func(error)
+}
+
+// InboxEvent is an event stored in the inbox.
+type InboxEvent struct {
+	ID        string
+	Type      string
+	Payload   []byte
+	Status    string
+	Attempts  int
+	LastError string
+	NextRun   time.Time
+	CreatedAt time.Time
+}
+
+func (ib Inbox) table() string {
+	if ib.Table == "" {
+		return "zstripe_inbox"
+	}
+	return ib.Table
+}
+
+func (ib Inbox) query(q string) string {
+	return ib.Dialect.rebind(fmt.Sprintf(q, ib.table()))
+}
+
+// Schema gets the CREATE TABLE statement for this inbox.
+func (ib Inbox) Schema() string {
+	payload := "blob"
+	if ib.Dialect == PostgreSQL {
+		payload = "bytea"
+	}
+	return fmt.Sprintf(`create table %[1]s (
+	id          varchar  not null primary key,
+	type        varchar  not null,
+	payload     %[2]s    not null,
+	status      varchar  not null,
+	attempts    integer  not null default 0,
+	last_error  varchar  not null default '',
+	next_run    bigint   not null,
+	created_at  bigint   not null
+);
+create index %[1]s_status on %[1]s(status, next_run);
+`, ib.table(), payload)
+}
+
+// Store the event. Events that are already stored are ignored.
+func (ib Inbox) Store(e Event, payload []byte) error {
+	now := time.Now().Unix()
+	_, err := ib.DB.Exec(ib.query(`insert into %s
+		(id, type, payload, status, attempts, last_error, next_run, created_at)
+		values (?, ?, ?, ?, 0, '', ?, ?) on conflict (id) do nothing`),
+		e.ID, e.Type, payload, InboxPending, now, now)
+	if err != nil {
+		return fmt.Errorf("zstripe.Inbox.Store: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP reads the event with Event.ReadRaw and stores the payload.
+//
+// This responds with 400 Bad Request if the event can't be read or verified,
+// and 500 Internal Server Error if it can't be stored, so Stripe will retry it
+// later.
+func (ib Inbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var e Event
+	payload, err := e.ReadRaw(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = ib.Store(e, payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Run processes events until the context is cancelled, checking for new
+// events every interval.
+func (ib Inbox) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		for {
+			n, err := ib.Process(100)
+			if err != nil {
+				if ib.Error != nil {
+					ib.Error(err)
+				} else {
+					fmt.Fprintf(os.Stderr, "zstripe.Inbox: %s\n", err)
+				}
+			}
+			if n == 0 || err != nil || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// Process claims up to limit events that are ready to run, and runs the
+// Handler for them.
+//
+// Claiming is atomic, so it's safe to run this from several processes at the
+// same time. Events for which the Handler returns an error or times out are
+// retried with a backoff, or marked as InboxDead after MaxAttempts.
+//
+// The number of events processed is returned.
+func (ib Inbox) Process(limit int) (int, error) {
+	var (
+		maxAttempts = ib.MaxAttempts
+		backoff     = ib.Backoff
+		timeout     = ib.Timeout
+		now         = time.Now()
+	)
+	if maxAttempts == 0 {
+		maxAttempts = 10
+	}
+	if backoff == 0 {
+		backoff = 30 * time.Second
+	}
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+
+	// Processing events have next_run set to when they time out.
+	rows, err := ib.DB.Query(ib.query(`select id from %s
+		where status in (?, ?) and next_run <= ? order by next_run, created_at limit ?`),
+		InboxPending, InboxProcessing, now.Unix(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("zstripe.Inbox.Process: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("zstripe.Inbox.Process: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("zstripe.Inbox.Process: %w", err)
+	}
+
+	n := 0
+	for _, id := range ids {
+		ok, ev, err := ib.claim(id, now, now.Add(timeout), maxAttempts)
+		if err != nil {
+			return n, fmt.Errorf("zstripe.Inbox.Process: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		n++
+
+		var e Event
+		hErr := json.Unmarshal(ev.Payload, &e)
+		if hErr == nil {
+			hErr = ib.Handler(e)
+		}
+		// Only update the event if nobody else claimed it since, which can happen
+		// if the Handler took longer than the Timeout.
+		if hErr == nil {
+			_, err = ib.DB.Exec(ib.query(`update %s set status=?, last_error=''
+				where id=? and status=? and attempts=?`),
+				InboxDone, id, InboxProcessing, ev.Attempts)
+		} else {
+			status, next := InboxPending, time.Now().Add(retryDelay(backoff, ev.Attempts))
+			if ev.Attempts >= maxAttempts {
+				status = InboxDead
+			}
+			_, err = ib.DB.Exec(ib.query(`update %s set status=?, last_error=?, next_run=?
+				where id=? and status=? and attempts=?`),
+				status, hErr.Error(), next.Unix(), id, InboxProcessing, ev.Attempts)
+		}
+		if err != nil {
+			return n, fmt.Errorf("zstripe.Inbox.Process: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// retryDelay gets the delay before retrying after the given number of
+// attempts: backoff doubled for every attempt after the first, up to 24 hours.
+func retryDelay(backoff time.Duration, attempts int) time.Duration {
+	const maxDelay = 24 * time.Hour
+	d := backoff
+	for i := 1; i < attempts && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
+
+// claim the event by setting it to processing, as long as nobody else claimed
+// it since we selected it.
+//
+// Events that already had maxAttempts attempts aren't claimed but marked as
+// dead; this only happens if the last attempt timed out.
+func (ib Inbox) claim(id string, now, timeout time.Time, maxAttempts int) (bool, InboxEvent, error) {
+	res, err := ib.DB.Exec(ib.query(`update %s set status=?, attempts=attempts+1, next_run=?
+		where id=? and status in (?, ?) and next_run <= ? and attempts < ?`),
+		InboxProcessing, timeout.Unix(), id, InboxPending, InboxProcessing, now.Unix(), maxAttempts)
+	if err != nil {
+		return false, InboxEvent{}, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, InboxEvent{}, err
+	}
+	if n != 1 {
+		_, err := ib.DB.Exec(ib.query(`update %s set status=?,
+			last_error=case when status=? then 'timed out' else last_error end
+			where id=? and status in (?, ?) and next_run <= ? and attempts >= ?`),
+			InboxDead, InboxProcessing, id, InboxPending, InboxProcessing, now.Unix(), maxAttempts)
+		return false, InboxEvent{}, err
+	}
+
+	ev, err := ib.Get(id)
+	return err == nil, ev, err
+}
+
+const inboxColumns = `id, type, payload, status, attempts, last_error, next_run, created_at`
+
+func scanInboxEvent(row interface{ Scan(...interface{}) error }) (InboxEvent, error) {
+	var (
+		ev               InboxEvent
+		nextRun, created int64
+	)
+	err := row.Scan(&ev.ID, &ev.Type, &ev.Payload, &ev.Status, &ev.Attempts, &ev.LastError, &nextRun, &created)
+	ev.NextRun, ev.CreatedAt = time.Unix(nextRun, 0), time.Unix(created, 0)
+	return ev, err
+}
+
+// Get an event from the inbox.
+func (ib Inbox) Get(id string) (InboxEvent, error) {
+	ev, err := scanInboxEvent(ib.DB.QueryRow(ib.query(`select `+inboxColumns+` from %s where id=?`), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ev, fmt.Errorf("zstripe.Inbox.Get: no event %q", id)
+		}
+		return ev, fmt.Errorf("zstripe.Inbox.Get: %w", err)
+	}
+	return ev, nil
+}
+
+// Dead lists events that failed MaxAttempts times, newest first.
+func (ib Inbox) Dead(limit int) ([]InboxEvent, error) {
+	rows, err := ib.DB.Query(ib.query(`select `+inboxColumns+` from %s
+		where status=? order by created_at desc limit ?`), InboxDead, limit)
+	if err != nil {
+		return nil, fmt.Errorf("zstripe.Inbox.Dead: %w", err)
+	}
+	defer rows.Close()
+
+	var events []InboxEvent
+	for rows.Next() {
+		ev, err := scanInboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("zstripe.Inbox.Dead: %w", err)
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("zstripe.Inbox.Dead: %w", err)
+	}
+	return events, nil
+}
+
+// Requeue a dead event, resetting the number of attempts.
+func (ib Inbox) Requeue(id string) error {
+	res, err := ib.DB.Exec(ib.query(`update %s set status=?, attempts=0, next_run=? where id=? and status=?`),
+		InboxPending, time.Now().Unix(), id, InboxDead)
+	if err != nil {
+		return fmt.Errorf("zstripe.Inbox.Requeue: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("zstripe.Inbox.Requeue: %w", err)
+	} else if n != 1 {
+		return fmt.Errorf("zstripe.Inbox.Requeue: no dead event %q", id)
+	}
+	return nil
+}
+
+// Expire removes all events that were processed successfully and stored more
+// than age ago.
+//
+// Events that are already stored are ignored by Store(), so this also decides
+// how long redelivered events are detected; Stripe retries events for up to
+// three days, so there's little point in keeping them much longer than that.
+func (ib Inbox) Expire(age time.Duration) error {
+	_, err := ib.DB.Exec(ib.query(`delete from %s where status=? and created_at < ?`),
+		InboxDone, time.Now().Add(-age).Unix())
+	if err != nil {
+		return fmt.Errorf("zstripe.Inbox.Expire: %w", err)
+	}
+	return nil
+}
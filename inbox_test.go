@@ -0,0 +1,261 @@
+package zstripe
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInbox(t *testing.T) {
+	var (
+		ib   = Inbox{MaxAttempts: 2, Backoff: time.Minute}
+		fail = map[string]bool{"evt_2": true}
+		ran  []string
+	)
+	ib.DB = testDB(t, ib.Schema())
+	ib.Handler = func(e Event) error {
+		ran = append(ran, e.ID)
+		if fail[e.ID] {
+			return errors.New("oh noes")
+		}
+		return nil
+	}
+
+	process := func(want ...string) {
+		t.Helper()
+		ran = nil
+		n, err := ib.Process(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(want) || strings.Join(ran, " ") != strings.Join(want, " ") {
+			t.Fatalf("processed %d: %v; want %v", n, ran, want)
+		}
+	}
+	check := func(id, status string, attempts int) InboxEvent {
+		t.Helper()
+		ev, err := ib.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Status != status || ev.Attempts != attempts {
+			t.Fatalf("%s: status %q, attempts %d; want %q, %d", id, ev.Status, ev.Attempts, status, attempts)
+		}
+		return ev
+	}
+	// Make the event ready to run, as if the backoff or timeout passed.
+	ready := func(id string) {
+		t.Helper()
+		_, err := ib.DB.Exec(`update zstripe_inbox set next_run=0 where id=?`, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, id := range []string{"evt_1", "evt_2", "evt_1"} {
+		err := ib.Store(Event{ID: id, Type: EventInvoicePaid}, []byte(`{"id":"`+id+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	process("evt_1", "evt_2")
+	check("evt_1", InboxDone, 1)
+	ev := check("evt_2", InboxPending, 1)
+	if ev.LastError != "oh noes" || time.Until(ev.NextRun) < 50*time.Second {
+		t.Fatalf("wrong retry: %q %s", ev.LastError, ev.NextRun)
+	}
+
+	// Not ready yet, and then dead after MaxAttempts.
+	process()
+	ready("evt_2")
+	process("evt_2")
+	check("evt_2", InboxDead, 2)
+	ready("evt_2")
+	process()
+
+	dead, err := ib.Dead(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].ID != "evt_2" || dead[0].Attempts != 2 || dead[0].LastError != "oh noes" {
+		t.Fatalf("dead: %v", dead)
+	}
+
+	if err := ib.Requeue("evt_2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ib.Requeue("evt_2"); err == nil {
+		t.Fatal("requeued non-dead event")
+	}
+	check("evt_2", InboxPending, 0)
+	fail["evt_2"] = false
+	process("evt_2")
+	check("evt_2", InboxDone, 1)
+}
+
+func TestInboxTimeout(t *testing.T) {
+	ib := Inbox{Timeout: time.Minute}
+	ib.DB = testDB(t, ib.Schema())
+	err := ib.Store(Event{ID: "evt_1"}, []byte(`{"id":"evt_1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run takes longer than the timeout, so the event is claimed
+	// again while it's still running; the first run finishing (or failing)
+	// shouldn't overwrite the second one.
+	var runs int
+	ib.Handler = func(e Event) error {
+		runs++
+		if runs > 1 {
+			return nil
+		}
+
+		_, err := ib.DB.Exec(`update zstripe_inbox set next_run=0`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := ib.Process(10); err != nil || n != 1 {
+			t.Fatalf("reclaim: %d %v", n, err)
+		}
+		return errors.New("took too long")
+	}
+
+	n, err := ib.Process(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || runs != 2 {
+		t.Fatalf("n=%d runs=%d", n, runs)
+	}
+	ev, err := ib.Get("evt_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Status != InboxDone || ev.Attempts != 2 || ev.LastError != "" {
+		t.Errorf("%+v", ev)
+	}
+}
+
+func TestInboxTimeoutDead(t *testing.T) {
+	ib := Inbox{MaxAttempts: 2}
+	ib.DB = testDB(t, ib.Schema())
+	err := ib.Store(Event{ID: "evt_1"}, []byte(`{"id":"evt_1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runs int
+	ib.Handler = func(e Event) error { runs++; return nil }
+
+	// Crashed (or timed out) while processing after the first and second
+	// attempt; the third claim should mark it as dead.
+	for i, want := range []int{1, 0} {
+		_, err := ib.DB.Exec(`update zstripe_inbox set status=?, attempts=?, next_run=0`, InboxProcessing, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := ib.Process(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want || runs != 1 {
+			t.Fatalf("attempt %d: n=%d runs=%d", i+1, n, runs)
+		}
+	}
+
+	ev, err := ib.Get("evt_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Status != InboxDead || ev.Attempts != 2 || ev.LastError != "timed out" {
+		t.Errorf("%+v", ev)
+	}
+}
+
+func TestInboxExpire(t *testing.T) {
+	var ib Inbox
+	ib.DB = testDB(t, ib.Schema())
+	ib.Handler = func(e Event) error { return nil }
+	store := func(id string) {
+		t.Helper()
+		err := ib.Store(Event{ID: id}, []byte(`{"id":"`+id+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	store("evt_1")
+	store("evt_2")
+	if _, err := ib.Process(10); err != nil {
+		t.Fatal(err)
+	}
+	store("evt_3")
+	_, err := ib.DB.Exec(`update zstripe_inbox set created_at=created_at-7200 where id in ('evt_1', 'evt_3')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only evt_1 is done and old enough.
+	if err := ib.Expire(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]bool{"evt_1": false, "evt_2": true, "evt_3": true} {
+		if _, err := ib.Get(id); (err == nil) != want {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+}
+
+func TestInboxServeHTTP(t *testing.T) {
+	SignSecret = "whsec_test"
+	defer func() { SignSecret = "" }()
+
+	var ib Inbox
+	ib.DB = testDB(t, ib.Schema())
+
+	payload := `{"id": "evt_1", "type": "invoice.paid"}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(payload))
+	r.Header.Set("Stripe-Signature", Sign([]byte(payload), time.Now()))
+	w := httptest.NewRecorder()
+	ib.ServeHTTP(w, r)
+	if w.Code != 200 {
+		t.Fatalf("%d: %s", w.Code, w.Body)
+	}
+
+	ev, err := ib.Get("evt_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != EventInvoicePaid || ev.Status != InboxPending || string(ev.Payload) != payload {
+		t.Errorf("%+v", ev)
+	}
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(payload))
+	w = httptest.NewRecorder()
+	ib.ServeHTTP(w, r)
+	if w.Code != 400 {
+		t.Fatalf("%d: %s", w.Code, w.Body)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{12, 1024 * time.Minute},
+		{13, 24 * time.Hour},
+		{40, 24 * time.Hour},
+		{1000, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(30*time.Second, tt.attempts); got != tt.want {
+			t.Errorf("%d: %s; want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type eventType struct {
+	Type, Resource, Action, Object, Description string
+	Connect, Deprecated                         bool
+}
+
+// Events that are only sent to Connect platforms, and deprecated resources.
+// These are not in the spec.
+var (
+	connectPrefixes    = []string{"account.", "application_fee.", "capability.", "person."}
+	deprecatedPrefixes = []string{"recipient.", "sku.", "order.", "order_return."}
+)
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// readExisting reads the event types from an existing events.go.
+func readExisting(path string) (map[string]eventType, error) {
+	existing := make(map[string]eventType)
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+	if errors.Is(err, os.ErrNotExist) {
+		return existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	consts := make(map[string]string) // EventFoo → "foo"
+	for _, d := range f.Decls {
+		d, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range d.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok || len(vs.Names) != 1 || len(vs.Values) != 1 {
+				continue
+			}
+
+			switch d.Tok {
+			case token.CONST:
+				typ := str(vs.Values[0])
+				consts[vs.Names[0].Name] = typ
+				existing[typ] = eventType{
+					Type:        typ,
+					Description: strings.Join(strings.Fields(vs.Doc.Text()), " "),
+				}
+			case token.VAR:
+				if vs.Names[0].Name != "EventTypes" {
+					continue
+				}
+				lit, ok := vs.Values[0].(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				for _, elt := range lit.Elts {
+					et := readEventType(elt, consts)
+					if et.Type != "" {
+						existing[et.Type] = et
+					}
+				}
+			}
+		}
+	}
+	return existing, nil
+}
+
+func readEventType(elt ast.Expr, consts map[string]string) eventType {
+	var et eventType
+	lit, ok := elt.(*ast.CompositeLit)
+	if !ok {
+		return et
+	}
+	for _, kv := range lit.Elts {
+		kv, ok := kv.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		k, _ := kv.Key.(*ast.Ident)
+		if k == nil {
+			continue
+		}
+		switch k.Name {
+		case "Type":
+			if id, ok := kv.Value.(*ast.Ident); ok {
+				et.Type = consts[id.Name]
+			} else {
+				et.Type = str(kv.Value)
+			}
+		case "Object":
+			et.Object = str(kv.Value)
+		case "Description":
+			et.Description = str(kv.Value)
+		case "Connect":
+			et.Connect = isTrue(kv.Value)
+		case "Deprecated":
+			et.Deprecated = isTrue(kv.Value)
+		}
+	}
+	return et
+}
+
+func str(e ast.Expr) string {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, _ := strconv.Unquote(lit.Value)
+	return s
+}
+
+func isTrue(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == "true"
+}
+
+// specEvents gets all event types from the spec.
+//
+// Newer versions of the spec have a schema with x-stripeEvent for every event,
+// which includes a description and the object type. All versions have the
+// list of types in the enabled_events parameter for webhook endpoints.
+func specEvents(s spec) (map[string]eventType, error) {
+	events := make(map[string]eventType)
+
+	for _, sch := range s.Components.Schemas {
+		if sch.StripeEvent == nil || sch.StripeEvent.Type == "" {
+			continue
+		}
+		et := eventType{Type: sch.StripeEvent.Type, Description: describe(sch.Description)}
+		if o := sch.Properties["object"]; o != nil && o.Ref != "" {
+			name, ref := s.ref(o.Ref)
+			et.Object = name
+			if ref != nil && ref.ResourceID != "" {
+				et.Object = ref.ResourceID
+			}
+		}
+		events[et.Type] = et
+	}
+
+	if op, ok := s.Paths["/v1/webhook_endpoints"]["post"]; ok {
+		for _, c := range op.RequestBody.Content {
+			if c.Schema == nil || c.Schema.Properties["enabled_events"] == nil {
+				continue
+			}
+			items := c.Schema.Properties["enabled_events"].Items
+			if items == nil {
+				continue
+			}
+			for _, e := range items.Enum {
+				typ, _ := e.(string)
+				if typ == "" || typ == "*" {
+					continue
+				}
+				if _, ok := events[typ]; !ok {
+					events[typ] = eventType{Type: typ}
+				}
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		return nil, errors.New("no events in the spec")
+	}
+	return events, nil
+}
+
+// describe converts "Occurs whenever a new customer is created." to "New
+// customer is created."
+func describe(d string) string {
+	d = strings.Join(strings.Fields(d), " ")
+	for _, p := range []string{"Occurs whenever ", "Occurs when ", "Occurs "} {
+		if strings.HasPrefix(d, p) {
+			d = strings.TrimPrefix(d, p)
+			break
+		}
+	}
+	for _, p := range []string{"a ", "an "} {
+		if strings.HasPrefix(d, p) {
+			d = strings.TrimPrefix(d, p)
+			break
+		}
+	}
+	if d == "" {
+		return ""
+	}
+	return strings.ToUpper(d[:1]) + d[1:]
+}
+
+func genEvents(pkg string, s spec, existing map[string]eventType) ([]byte, error) {
+	events, err := specEvents(s)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, 0, len(events))
+	for typ, et := range events {
+		i := strings.LastIndexByte(typ, '.')
+		if i == -1 {
+			return nil, fmt.Errorf("invalid event type: %q", typ)
+		}
+		et.Resource, et.Action = typ[:i], typ[i+1:]
+
+		old := existing[typ]
+		if old.Description != "" {
+			et.Description = old.Description
+		}
+		if et.Description == "" {
+			et.Description = strings.ToUpper(et.Resource[:1]) + strings.ReplaceAll(et.Resource[1:], "_", " ") +
+				" is " + strings.ReplaceAll(et.Action, "_", " ") + "."
+		}
+		if et.Object == "" {
+			et.Object = old.Object
+		}
+		if et.Object == "" {
+			et.Object = et.Resource[strings.LastIndexByte(et.Resource, '.')+1:]
+		}
+		et.Connect = old.Connect || hasPrefix(typ, connectPrefixes)
+		et.Deprecated = old.Deprecated || hasPrefix(typ, deprecatedPrefixes)
+
+		events[typ] = et
+		types = append(types, typ)
+	}
+	// Sort by the top-level resource, with the events for that resource before
+	// the events for sub-resources (charge.updated before charge.dispute.*).
+	sort.Slice(types, func(i, j int) bool {
+		a, b := strings.Split(types[i], "."), strings.Split(types[j], ".")
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return types[i] < types[j]
+	})
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(header)
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	fmt.Fprintf(buf, "// List of webhook events; API version %s.\nconst (\n", s.Info.Version)
+	for i, typ := range types {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		comment(buf, "\t", events[typ].Description)
+		fmt.Fprintf(buf, "\tEvent%s = %q\n", camel(typ), typ)
+	}
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// EventTypes is a list of all known webhook event types.\nvar EventTypes = []EventType{\n")
+	for _, typ := range types {
+		et := events[typ]
+		fmt.Fprintf(buf, "\t{Type: Event%s, Resource: %q, Action: %q, Object: %q", camel(typ), et.Resource, et.Action, et.Object)
+		if et.Connect {
+			buf.WriteString(", Connect: true")
+		}
+		if et.Deprecated {
+			buf.WriteString(", Deprecated: true")
+		}
+		fmt.Fprintf(buf, ",\n\t\tDescription: %q},\n", et.Description)
+	}
+	buf.WriteString("}\n")
+
+	return buf.Bytes(), nil
+}
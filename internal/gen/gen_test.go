@@ -0,0 +1,83 @@
+package main
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+// contains reports if want is in out, ignoring differences in whitespace.
+func contains(out []byte, want string) bool {
+	return strings.Contains(strings.Join(strings.Fields(string(out)), " "), strings.Join(strings.Fields(want), " "))
+}
+
+func TestGen(t *testing.T) {
+	s, err := readSpec("testdata/spec.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := map[string]eventType{
+		"customer.created": {Type: "customer.created", Description: "New customer is created.", Object: "customer"},
+	}
+	out, err := genEvents("zstripe", s, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = format.Source(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"API version 2024-06-20",
+		"\t// New customer is created.\n\tEventCustomerCreated = \"customer.created\"\n",
+		"\t// Invoice is about to be due.\n\tEventInvoiceWillBeDue = \"invoice.will_be_due\"\n",
+		"\t// Invoice is paid.\n\tEventInvoicePaid = \"invoice.paid\"\n",
+		`{Type: EventInvoiceWillBeDue, Resource: "invoice", Action: "will_be_due", Object: "invoice",`,
+		`{Type: EventSkuCreated, Resource: "sku", Action: "created", Object: "sku", Deprecated: true,`,
+	} {
+		if !contains(out, want) {
+			t.Errorf("events: missing %q\n%s", want, out)
+		}
+	}
+
+	out, err = genResources("zstripe", s, []string{"invoice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = format.Source(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"import \"encoding/json\"",
+		"// Invoice is the \"invoice\" object.\n//\n// Invoices are statements of amounts owed by a customer.\ntype Invoice struct {",
+		"AmountDue        int64             `json:\"amount_due\"`",
+		"Customer         json.RawMessage   `json:\"customer\"`",
+		"HostedInvoiceURL string            `json:\"hosted_invoice_url\"`",
+		"// Unique identifier for the object.\n\tID string `json:\"id\"`",
+		"Lines            []json.RawMessage `json:\"lines\"`",
+		"Metadata         map[string]string `json:\"metadata\"`",
+	} {
+		if !contains(out, want) {
+			t.Errorf("resources: missing %q\n%s", want, out)
+		}
+	}
+
+	out, err = genPaths("zstripe", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = format.Source(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`PathV1Customers                   = "/v1/customers"`,
+		`PathV1CustomersCustomerSourcesId = "/v1/customers/%s/sources/%s"`,
+	} {
+		if !contains(out, want) {
+			t.Errorf("paths: missing %q\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Command gen generates the Event* constants and EventTypes from Stripe's
+// OpenAPI spec, and optionally resource structs and API path constants.
+//
+// Get a copy of the spec for the API version you want from
+// https://github.com/stripe/openapi and run "go generate" with STRIPE_OPENAPI
+// set:
+//
+//	STRIPE_OPENAPI=~/stripe-openapi/openapi/spec3.json go generate
+//
+// Descriptions, object types, and the Connect and Deprecated flags for event
+// types already in events.go are kept if the spec doesn't have them.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		specFile  = flag.String("spec", "", "Path to Stripe's OpenAPI spec (spec3.json); required.")
+		events    = flag.String("events", "events.go", "Write event types to this file; existing descriptions are kept.")
+		resources = flag.String("resources", "", "Comma-separated list of schemas to generate structs for, e.g. \"customer,invoice\".")
+		resOut    = flag.String("resources-out", "resources.go", "Write resource structs to this file.")
+		paths     = flag.String("paths", "", "Write API path constants to this file.")
+		pkg       = flag.String("pkg", "zstripe", "Package name.")
+	)
+	flag.Parse()
+	if *specFile == "" {
+		fmt.Fprintln(os.Stderr, "gen: must set -spec (or $STRIPE_OPENAPI for go generate)")
+		os.Exit(2)
+	}
+
+	s, err := readSpec(*specFile)
+	if err != nil {
+		fatal(err)
+	}
+
+	existing, err := readExisting(*events)
+	if err != nil {
+		fatal(err)
+	}
+	out, err := genEvents(*pkg, s, existing)
+	if err != nil {
+		fatal(err)
+	}
+	err = write(*events, out)
+	if err != nil {
+		fatal(err)
+	}
+
+	if *resources != "" {
+		out, err := genResources(*pkg, s, strings.Split(*resources, ","))
+		if err != nil {
+			fatal(err)
+		}
+		err = write(*resOut, out)
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	if *paths != "" {
+		out, err := genPaths(*pkg, s)
+		if err != nil {
+			fatal(err)
+		}
+		err = write(*paths, out)
+		if err != nil {
+			fatal(err)
+		}
+	}
+}
+
+type (
+	spec struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]operation `json:"paths"`
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+
+	operation struct {
+		Description string `json:"description"`
+		RequestBody struct {
+			Content map[string]struct {
+				Schema *schema `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+	}
+
+	schema struct {
+		Ref                  string             `json:"$ref"`
+		Type                 string             `json:"type"`
+		Format               string             `json:"format"`
+		Description          string             `json:"description"`
+		Nullable             bool               `json:"nullable"`
+		Enum                 []interface{}      `json:"enum"`
+		Properties           map[string]*schema `json:"properties"`
+		Items                *schema            `json:"items"`
+		AnyOf                []*schema          `json:"anyOf"`
+		AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+		ResourceID           string             `json:"x-resourceId"`
+		StripeEvent          *struct {
+			Type string `json:"type"`
+		} `json:"x-stripeEvent"`
+	}
+)
+
+func readSpec(path string) (spec, error) {
+	var s spec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ref gets the schema for a "#/components/schemas/name" reference.
+func (s spec) ref(ref string) (string, *schema) {
+	name := strings.TrimPrefix(ref, "#/components/schemas/")
+	return name, s.Components.Schemas[name]
+}
+
+const header = "// Code generated by internal/gen from the Stripe OpenAPI spec; DO NOT EDIT.\n\n"
+
+func write(path string, src []byte) error {
+	out, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w\n%s", path, err, src)
+	}
+	return os.WriteFile(path, out, 0o666)
+}
+
+// camel converts "tax_id.created" to "TaxIdCreated".
+func camel(s string) string {
+	var b strings.Builder
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-' || r == '/' || r == '{' || r == '}'
+	}) {
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// comment formats text as a comment wrapped at 80 columns.
+func comment(buf *bytes.Buffer, indent, text string) {
+	var (
+		width = 80 - len(indent)*4 - 3
+		line  []string
+		n     int
+	)
+	for _, w := range strings.Fields(text) {
+		if n > 0 && n+1+len(w) > width {
+			fmt.Fprintf(buf, "%s// %s\n", indent, strings.Join(line, " "))
+			line, n = nil, 0
+		}
+		if n > 0 {
+			n++
+		}
+		line = append(line, w)
+		n += len(w)
+	}
+	if len(line) > 0 {
+		fmt.Fprintf(buf, "%s// %s\n", indent, strings.Join(line, " "))
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "gen: %s\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// genResources generates structs for the named schemas.
+//
+// Expandable fields and other references are json.RawMessage, since they can
+// be either an ID or the full object.
+func genResources(pkg string, s spec, names []string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		sch, ok := s.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("no schema %q", name)
+		}
+
+		buf.WriteByte('\n')
+		comment(buf, "", fmt.Sprintf("%s is the %q object.", camel(name), name))
+		if sch.Description != "" {
+			buf.WriteString("//\n")
+			comment(buf, "", firstSentence(sch.Description))
+		}
+		fmt.Fprintf(buf, "type %s struct {\n", camel(name))
+
+		props := make([]string, 0, len(sch.Properties))
+		for p := range sch.Properties {
+			props = append(props, p)
+		}
+		sort.Strings(props)
+		for _, p := range props {
+			prop := sch.Properties[p]
+			if prop.Description != "" {
+				comment(buf, "\t", firstSentence(prop.Description))
+			}
+			fmt.Fprintf(buf, "\t%s %s `json:\"%s\"`\n", fieldName(p), goType(prop), p)
+		}
+		buf.WriteString("}\n")
+	}
+
+	out := new(bytes.Buffer)
+	out.WriteString(header)
+	fmt.Fprintf(out, "package %s\n\n", pkg)
+	if bytes.Contains(buf.Bytes(), []byte("json.RawMessage")) {
+		out.WriteString("import \"encoding/json\"\n\n")
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+func goType(sch *schema) string {
+	if sch.Ref != "" || len(sch.AnyOf) > 0 {
+		return "json.RawMessage"
+	}
+	switch sch.Type {
+	case "string":
+		return "string"
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "array":
+		if sch.Items == nil {
+			return "[]json.RawMessage"
+		}
+		return "[]" + goType(sch.Items)
+	case "object":
+		if len(sch.Properties) == 0 && strings.Contains(string(sch.AdditionalProperties), `"string"`) {
+			return "map[string]string"
+		}
+	}
+	return "json.RawMessage"
+}
+
+// genPaths generates constants for all API paths, with the parameters
+// replaced by %s for use with fmt.Sprintf:
+//
+//	PathV1CustomersCustomerSources = "/v1/customers/%s/sources"
+func genPaths(pkg string, s spec) ([]byte, error) {
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(header)
+	fmt.Fprintf(buf, "package %s\n\n// API paths; API version %s.\nconst (\n", pkg, s.Info.Version)
+	for _, p := range paths {
+		var (
+			val  []string
+			name = "Path"
+		)
+		for _, seg := range strings.Split(strings.Trim(p, "/"), "/") {
+			name += camel(seg)
+			if strings.HasPrefix(seg, "{") {
+				seg = "%s"
+			}
+			val = append(val, seg)
+		}
+		fmt.Fprintf(buf, "\t%s = %q\n", name, "/"+strings.Join(val, "/"))
+	}
+	buf.WriteString(")\n")
+	return buf.Bytes(), nil
+}
+
+func fieldName(p string) string {
+	n := camel(p)
+	for _, abbr := range []string{"Id", "Url", "Api"} {
+		if strings.HasSuffix(n, abbr) {
+			n = n[:len(n)-len(abbr)] + strings.ToUpper(abbr)
+		}
+	}
+	return n
+}
+
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i > -1 {
+		return s[:i+1]
+	}
+	return s
+}
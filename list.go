@@ -0,0 +1,53 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// List calls fn for every object of a list endpoint, such as /v1/customers.
+//
+// This pages through all results with starting_after, so it may make many
+// requests. The params are added to every request; e.g. "limit" or
+// "created[gte]". Objects are returned in the order the API returns them, which
+// is usually newest first.
+//
+// Any error from fn is returned as-is and stops the listing.
+func List(path string, params Body, fn func(json.RawMessage) error) error {
+	after := ""
+	for {
+		p := make(Body, len(params)+1)
+		for k, v := range params {
+			p[k] = v
+		}
+		if after != "" {
+			p["starting_after"] = after
+		}
+
+		var page struct {
+			Data    []json.RawMessage `json:"data"`
+			HasMore bool              `json:"has_more"`
+		}
+		_, err := Request(&page, "GET", path+"?"+p.Encode(), "")
+		if err != nil {
+			return err
+		}
+
+		for _, o := range page.Data {
+			err := fn(o)
+			if err != nil {
+				return err
+			}
+		}
+		if !page.HasMore || len(page.Data) == 0 {
+			return nil
+		}
+
+		var last eventObject
+		err = json.Unmarshal(page.Data[len(page.Data)-1], &last)
+		if err != nil {
+			return fmt.Errorf("zstripe.List: %w", err)
+		}
+		after = last.ID
+	}
+}
@@ -0,0 +1,284 @@
+package zstripe
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Mirror keeps a copy of customers, subscriptions, invoices, and prices in
+// SQL tables, so they can be queried without using the API.
+//
+// Use Import() to copy all existing objects, and Handle() for webhook events
+// to keep them updated. The tables must exist; see Schema().
+//
+// Every row has the raw JSON and some typed columns for common queries, the
+// time it was last updated (the event's creation time, or the import time),
+// and a deleted flag. Deleted rows are kept so that updates delivered after
+// the deletion don't re-create them. Updates older than the stored row are
+// ignored.
+type Mirror struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Prefix  string // Table name prefix; defaults to "stripe_".
+}
+
+// mirrorTable is a table in the mirror.
+type mirrorTable struct {
+	object string // Stripe object type.
+	path   string // List endpoint.
+	params Body
+	name   string
+	schema string   // With %[1]s for the table name and %[2]s for the JSON type.
+	cols   []string // Typed columns, in the order values returns them.
+	values func(json.RawMessage) (string, []interface{}, error)
+}
+
+var mirrorTables = []mirrorTable{
+	{
+		object: "customer", path: "/v1/customers", name: "customers",
+		schema: `create table %[1]s (
+	id       varchar  not null primary key,
+	email    varchar  not null,
+	name     varchar  not null,
+	created  bigint   not null,
+	deleted  integer  not null default 0,
+	updated  bigint   not null,
+	raw      %[2]s    not null
+);
+create index %[1]s_email on %[1]s(email);
+`,
+		cols: []string{"email", "name", "created"},
+		values: func(b json.RawMessage) (string, []interface{}, error) {
+			var o struct {
+				ID      string `json:"id"`
+				Email   string `json:"email"`
+				Name    string `json:"name"`
+				Created int64  `json:"created"`
+			}
+			err := json.Unmarshal(b, &o)
+			return o.ID, []interface{}{o.Email, o.Name, o.Created}, err
+		},
+	},
+
+	{
+		object: "subscription", path: "/v1/subscriptions", params: Body{"status": "all"}, name: "subscriptions",
+		schema: `create table %[1]s (
+	id                    varchar  not null primary key,
+	customer              varchar  not null,
+	status                varchar  not null,
+	current_period_end    bigint   not null,
+	cancel_at_period_end  integer  not null,
+	created               bigint   not null,
+	deleted               integer  not null default 0,
+	updated               bigint   not null,
+	raw                   %[2]s    not null
+);
+create index %[1]s_customer on %[1]s(customer);
+`,
+		cols: []string{"customer", "status", "current_period_end", "cancel_at_period_end", "created"},
+		values: func(b json.RawMessage) (string, []interface{}, error) {
+			var o struct {
+				ID                string          `json:"id"`
+				Customer          json.RawMessage `json:"customer"`
+				Status            string          `json:"status"`
+				CurrentPeriodEnd  int64           `json:"current_period_end"`
+				CancelAtPeriodEnd bool            `json:"cancel_at_period_end"`
+				Created           int64           `json:"created"`
+			}
+			err := json.Unmarshal(b, &o)
+			return o.ID, []interface{}{expandableID(o.Customer), o.Status, o.CurrentPeriodEnd,
+				boolInt(o.CancelAtPeriodEnd), o.Created}, err
+		},
+	},
+
+	{
+		object: "invoice", path: "/v1/invoices", name: "invoices",
+		schema: `create table %[1]s (
+	id            varchar  not null primary key,
+	customer      varchar  not null,
+	subscription  varchar  not null,
+	status        varchar  not null,
+	currency      varchar  not null,
+	amount_due    bigint   not null,
+	amount_paid   bigint   not null,
+	created       bigint   not null,
+	deleted       integer  not null default 0,
+	updated       bigint   not null,
+	raw           %[2]s    not null
+);
+create index %[1]s_customer on %[1]s(customer);
+`,
+		cols: []string{"customer", "subscription", "status", "currency", "amount_due", "amount_paid", "created"},
+		values: func(b json.RawMessage) (string, []interface{}, error) {
+			var o struct {
+				ID           string          `json:"id"`
+				Customer     json.RawMessage `json:"customer"`
+				Subscription json.RawMessage `json:"subscription"`
+				Status       string          `json:"status"`
+				Currency     string          `json:"currency"`
+				AmountDue    int64           `json:"amount_due"`
+				AmountPaid   int64           `json:"amount_paid"`
+				Created      int64           `json:"created"`
+			}
+			err := json.Unmarshal(b, &o)
+			return o.ID, []interface{}{expandableID(o.Customer), expandableID(o.Subscription), o.Status,
+				o.Currency, o.AmountDue, o.AmountPaid, o.Created}, err
+		},
+	},
+
+	{
+		object: "price", path: "/v1/prices", name: "prices",
+		schema: `create table %[1]s (
+	id                  varchar  not null primary key,
+	product             varchar  not null,
+	active              integer  not null,
+	currency            varchar  not null,
+	unit_amount         bigint   not null,
+	recurring_interval  varchar  not null,
+	created             bigint   not null,
+	deleted             integer  not null default 0,
+	updated             bigint   not null,
+	raw                 %[2]s    not null
+);
+create index %[1]s_product on %[1]s(product);
+`,
+		cols: []string{"product", "active", "currency", "unit_amount", "recurring_interval", "created"},
+		values: func(b json.RawMessage) (string, []interface{}, error) {
+			var o struct {
+				ID         string          `json:"id"`
+				Product    json.RawMessage `json:"product"`
+				Active     bool            `json:"active"`
+				Currency   string          `json:"currency"`
+				UnitAmount int64           `json:"unit_amount"`
+				Created    int64           `json:"created"`
+				Recurring  struct {
+					Interval string `json:"interval"`
+				} `json:"recurring"`
+			}
+			err := json.Unmarshal(b, &o)
+			return o.ID, []interface{}{expandableID(o.Product), boolInt(o.Active), o.Currency, o.UnitAmount,
+				o.Recurring.Interval, o.Created}, err
+		},
+	},
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (m Mirror) table(t mirrorTable) string {
+	if m.Prefix == "" {
+		return "stripe_" + t.name
+	}
+	return m.Prefix + t.name
+}
+
+// Schema gets the CREATE TABLE statements for all tables.
+func (m Mirror) Schema() string {
+	var b strings.Builder
+	for i, t := range mirrorTables {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, t.schema, m.table(t), m.jsonType())
+	}
+	return b.String()
+}
+
+func (m Mirror) jsonType() string {
+	if m.Dialect == PostgreSQL {
+		return "jsonb"
+	}
+	return "text"
+}
+
+// Import copies all existing objects from the API.
+//
+// Objects that were updated by an event created after the import started are
+// left alone, so it's safe to run this while also handling webhooks.
+func (m Mirror) Import() error {
+	now := time.Now()
+	for _, t := range mirrorTables {
+		params := Body{"limit": "100"}
+		for k, v := range t.params {
+			params[k] = v
+		}
+		err := List(t.path, params, func(o json.RawMessage) error {
+			return m.store(t, o, false, now)
+		})
+		if err != nil {
+			return fmt.Errorf("zstripe.Mirror.Import: %s: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+// Handle updates the mirror from the event; events for other objects are
+// ignored. This can be used as an EventHandler.
+//
+// Events for objects without an ID are also ignored; for example the invoice in
+// invoice.upcoming doesn't exist yet.
+func (m Mirror) Handle(e Event) error {
+	var o struct {
+		ID     string `json:"id"`
+		Object string `json:"object"`
+	}
+	err := json.Unmarshal(e.Data.Raw, &o)
+	if err != nil {
+		return fmt.Errorf("zstripe.Mirror.Handle: %w", err)
+	}
+	if o.ID == "" {
+		return nil
+	}
+
+	for _, t := range mirrorTables {
+		if t.object == o.Object {
+			// Subscriptions are never deleted, just canceled.
+			deleted := strings.HasSuffix(e.Type, ".deleted") && t.object != "subscription"
+			err := m.store(t, e.Data.Raw, deleted, time.Unix(e.Created, 0))
+			if err != nil {
+				return fmt.Errorf("zstripe.Mirror.Handle: %s: %w", e.ID, err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m Mirror) store(t mirrorTable, raw json.RawMessage, deleted bool, updated time.Time) error {
+	id, vals, err := t.values(raw)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("no ID for %s", t.object)
+	}
+
+	args := append([]interface{}{id}, vals...)
+	args = append(args, boolInt(deleted), updated.Unix(), string(raw))
+	_, err = m.DB.Exec(m.upsert(t), args...)
+	return err
+}
+
+// upsert gets the query to insert or update a row. Rows are only updated if
+// they're not newer than the new data, and deleted rows are never un-deleted.
+func (m Mirror) upsert(t mirrorTable) string {
+	set := make([]string, 0, len(t.cols)+3)
+	for _, c := range t.cols {
+		set = append(set, c+"=excluded."+c)
+	}
+	set = append(set, "deleted=excluded.deleted", "updated=excluded.updated", "raw=excluded.raw")
+
+	return m.Dialect.rebind(fmt.Sprintf(`insert into %[1]s (id, %[2]s, deleted, updated, raw)
+		values (?, %[3]s?, ?, cast(? as %[4]s))
+		on conflict (id) do update set %[5]s
+		where %[1]s.updated <= excluded.updated and (%[1]s.deleted = 0 or excluded.deleted = 1)`,
+		m.table(t), strings.Join(t.cols, ", "), strings.Repeat("?, ", len(t.cols)), m.jsonType(),
+		strings.Join(set, ", ")))
+}
@@ -0,0 +1,166 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMirrorSQL(t *testing.T) {
+	m := Mirror{Dialect: PostgreSQL}
+	if s := m.Schema(); strings.Count(s, "raw                   jsonb    not null") != 1 || strings.Contains(s, " text ") {
+		t.Errorf("schema:\n%s", s)
+	}
+
+	want := `insert into stripe_customers (id, email, name, created, deleted, updated, raw)
+		values ($1, $2, $3, $4, $5, $6, cast($7 as jsonb))
+		on conflict (id) do update set email=excluded.email, name=excluded.name, created=excluded.created, deleted=excluded.deleted, updated=excluded.updated, raw=excluded.raw
+		where stripe_customers.updated <= excluded.updated and (stripe_customers.deleted = 0 or excluded.deleted = 1)`
+	if got := m.upsert(mirrorTables[0]); got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMirrorHandle(t *testing.T) {
+	m := Mirror{Prefix: "s_"}
+	m.DB = testDB(t, m.Schema())
+
+	event := func(typ string, created int64, obj string) Event {
+		e := Event{ID: fmt.Sprintf("evt_%d", created), Type: typ, Created: created}
+		e.Data.Raw = json.RawMessage(obj)
+		return e
+	}
+	check := func(table, id, col, want string) {
+		t.Helper()
+		var got string
+		err := m.DB.QueryRow(`select `+col+` || ' ' || deleted || ' ' || updated from s_`+table+` where id=?`, id).
+			Scan(&got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s %s: %q; want %q", table, id, got, want)
+		}
+	}
+
+	tests := []struct {
+		e                    Event
+		table, id, col, want string
+	}{
+		{event(EventCustomerCreated, 10, `{"id": "cus_1", "object": "customer", "email": "a@example.com"}`),
+			"customers", "cus_1", "email", "a@example.com 0 10"},
+
+		// Older update is ignored, but the same second is fine.
+		{event(EventCustomerUpdated, 5, `{"id": "cus_1", "object": "customer", "email": "old@example.com"}`),
+			"customers", "cus_1", "email", "a@example.com 0 10"},
+		{event(EventCustomerUpdated, 10, `{"id": "cus_1", "object": "customer", "email": "b@example.com"}`),
+			"customers", "cus_1", "email", "b@example.com 0 10"},
+
+		// Deleted rows are kept, and never un-deleted.
+		{event(EventCustomerDeleted, 20, `{"id": "cus_1", "object": "customer", "email": "b@example.com"}`),
+			"customers", "cus_1", "email", "b@example.com 1 20"},
+		{event(EventCustomerUpdated, 20, `{"id": "cus_1", "object": "customer", "email": "c@example.com"}`),
+			"customers", "cus_1", "email", "b@example.com 1 20"},
+		{event(EventCustomerUpdated, 30, `{"id": "cus_1", "object": "customer", "email": "c@example.com"}`),
+			"customers", "cus_1", "email", "b@example.com 1 20"},
+
+		// Update before create.
+		{event(EventCustomerUpdated, 20, `{"id": "cus_2", "object": "customer", "email": "new@example.com"}`),
+			"customers", "cus_2", "email", "new@example.com 0 20"},
+		{event(EventCustomerCreated, 10, `{"id": "cus_2", "object": "customer", "email": "created@example.com"}`),
+			"customers", "cus_2", "email", "new@example.com 0 20"},
+
+		// Subscriptions are canceled rather than deleted.
+		{event(EventCustomerSubscriptionDeleted, 10,
+			`{"id": "sub_1", "object": "subscription", "customer": {"id": "cus_2"}, "status": "canceled"}`),
+			"subscriptions", "sub_1", "customer || ' ' || status", "cus_2 canceled 0 10"},
+
+		{event(EventInvoiceDeleted, 10, `{"id": "in_1", "object": "invoice", "customer": "cus_2", "amount_due": 500}`),
+			"invoices", "in_1", "amount_due", "500 1 10"},
+		{event(EventPriceCreated, 10,
+			`{"id": "price_1", "object": "price", "product": {"id": "prod_1"}, "unit_amount": 100, "recurring": {"interval": "month"}}`),
+			"prices", "price_1", "product || ' ' || recurring_interval", "prod_1 month 0 10"},
+	}
+
+	for _, tt := range tests {
+		err := m.Handle(tt.e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(tt.table, tt.id, tt.col, tt.want)
+	}
+
+	// Other objects and objects without an ID are ignored.
+	err := m.Handle(event(EventChargeSucceeded, 10, `{"id": "ch_1", "object": "charge"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Handle(event(EventInvoiceUpcoming, 10, `{"object": "invoice", "customer": "cus_2", "amount_due": 500}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	err = m.DB.QueryRow(`select count(*) from s_invoices`).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("%d invoices", n)
+	}
+}
+
+func TestMirrorImport(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/customers":
+			w.Write([]byte(`{"data": [{"id": "cus_1", "object": "customer", "email": "import@example.com"},
+				{"id": "cus_2", "object": "customer", "email": "import@example.com"}]}`))
+		case "/v1/subscriptions":
+			if r.URL.Query().Get("status") != "all" {
+				t.Errorf("status not set: %s", r.URL)
+			}
+			w.Write([]byte(`{"data": [{"id": "sub_1", "object": "subscription", "customer": "cus_1", "status": "active"}]}`))
+		default:
+			w.Write([]byte(`{"data": []}`))
+		}
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	var m Mirror
+	m.DB = testDB(t, m.Schema())
+
+	// Event from after the import started.
+	e := Event{Type: EventCustomerUpdated, Created: time.Now().Add(time.Hour).Unix()}
+	e.Data.Raw = json.RawMessage(`{"id": "cus_2", "object": "customer", "email": "event@example.com"}`)
+	if err := m.Handle(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Import(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := m.DB.Query(`select id || ' ' || email from stripe_customers union all
+		select id || ' ' || status from stripe_subscriptions order by 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, s)
+	}
+	want := "cus_1 import@example.com, cus_2 event@example.com, sub_1 active"
+	if g := strings.Join(got, ", "); g != want {
+		t.Errorf("\ngot:  %s\nwant: %s", g, want)
+	}
+}
@@ -0,0 +1,215 @@
+package zstripe
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Errors for OAuthCallback.
+var (
+	ErrOAuthState  = errors.New("zstripe.OAuthCallback: state doesn't match")
+	ErrOAuthDenied = errors.New("zstripe.OAuthCallback: user denied access")
+)
+
+// OAuthToken is the response from the Connect OAuth token endpoint.
+type OAuthToken struct {
+	AccessToken          string `json:"access_token"`
+	RefreshToken         string `json:"refresh_token"`
+	TokenType            string `json:"token_type"`
+	Scope                string `json:"scope"`
+	Livemode             bool   `json:"livemode"`
+	StripeUserID         string `json:"stripe_user_id"` // Connected account ID (acct_*).
+	StripePublishableKey string `json:"stripe_publishable_key"`
+}
+
+// AuthorizeURL gets the URL to send users to for connecting their account with
+// OAuth, and a random state to protect against CSRF.
+//
+// Store the state (e.g. in the session) and pass it to OAuthCallback. The
+// params are added to the URL, e.g. "redirect_uri" or "stripe_user[email]".
+// The scope defaults to read_write.
+//
+// This uses ClientID, which must be set.
+func AuthorizeURL(params Body) (string, string) {
+	if ClientID == "" {
+		panic("zstripe.AuthorizeURL: must set zstripe.ClientID")
+	}
+
+	state := rnd()
+	q := make(url.Values)
+	q.Set("response_type", "code")
+	q.Set("scope", "read_write")
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	q.Set("client_id", ClientID)
+	q.Set("state", state)
+	return ConnectAPI + "/oauth/authorize?" + q.Encode(), state
+}
+
+// OAuthCallback handles the redirect back from AuthorizeURL: it checks the
+// state and exchanges the code with ExchangeOAuthCode.
+//
+// ErrOAuthState is returned if the state doesn't match, and ErrOAuthDenied if
+// the user declined to connect their account.
+func OAuthCallback(r *http.Request, state string) (OAuthToken, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(r.FormValue("state")), []byte(state)) != 1 {
+		return OAuthToken{}, ErrOAuthState
+	}
+	if e := r.FormValue("error"); e != "" {
+		if e == "access_denied" {
+			return OAuthToken{}, ErrOAuthDenied
+		}
+		return OAuthToken{}, fmt.Errorf("zstripe.OAuthCallback: %s: %s", e, r.FormValue("error_description"))
+	}
+	return ExchangeOAuthCode(r.FormValue("code"))
+}
+
+// ExchangeOAuthCode exchanges an authorization code for an access token and
+// the connected account's ID.
+func ExchangeOAuthCode(code string) (OAuthToken, error) {
+	var t OAuthToken
+	_, err := Request(&t, "POST", ConnectAPI+"/oauth/token", Body{
+		"grant_type": "authorization_code",
+		"code":       code,
+	}.Encode())
+	if err != nil {
+		return t, fmt.Errorf("zstripe.ExchangeOAuthCode: %w", err)
+	}
+	return t, nil
+}
+
+// DeauthorizeAccount disconnects a connected account from your platform.
+func DeauthorizeAccount(account string) error {
+	if ClientID == "" {
+		panic("zstripe.DeauthorizeAccount: must set zstripe.ClientID")
+	}
+	_, err := Request(nil, "POST", ConnectAPI+"/oauth/deauthorize", Body{
+		"client_id":      ClientID,
+		"stripe_user_id": account,
+	}.Encode())
+	if err != nil {
+		return fmt.Errorf("zstripe.DeauthorizeAccount: %w", err)
+	}
+	return nil
+}
+
+// Types for CreateAccountLink.
+const (
+	AccountOnboarding = "account_onboarding"
+	AccountUpdate     = "account_update"
+)
+
+// AccountLink is a single-use link to Stripe's hosted onboarding for Express
+// and Custom accounts.
+type AccountLink struct {
+	URL       string `json:"url"`
+	Created   int64  `json:"created"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// CreateAccountLink creates an account link of the given type
+// (AccountOnboarding or AccountUpdate).
+//
+// The user is sent to refreshURL if the link expired or was already used;
+// create a new link there. They're sent to returnURL when they leave the flow,
+// which doesn't mean onboarding is complete: use the account.updated event or
+// retrieve the account to check.
+func CreateAccountLink(account, typ, refreshURL, returnURL string) (AccountLink, error) {
+	var l AccountLink
+	_, err := Request(&l, "POST", "/v1/account_links", Body{
+		"account":     account,
+		"type":        typ,
+		"refresh_url": refreshURL,
+		"return_url":  returnURL,
+	}.Encode())
+	if err != nil {
+		return l, fmt.Errorf("zstripe.CreateAccountLink: %w", err)
+	}
+	return l, nil
+}
+
+// Onboarding statuses for AccountStatus.
+const (
+	OnboardingPending      = "pending"      // Details not submitted yet.
+	OnboardingRestricted   = "restricted"   // Submitted, but charges or payouts are disabled.
+	OnboardingComplete     = "complete"     // Charges and payouts are enabled.
+	OnboardingDeauthorized = "deauthorized" // Account disconnected from the platform.
+)
+
+// AccountStatus is the onboarding status of a connected account.
+type AccountStatus struct {
+	Account          string
+	Status           string // One of the Onboarding* constants.
+	ChargesEnabled   bool
+	PayoutsEnabled   bool
+	DetailsSubmitted bool
+	CurrentlyDue     []string // Requirements that need to be collected.
+	DisabledReason   string
+}
+
+// AccountStatus gets the onboarding status from an account.updated or
+// account.application.deauthorized event.
+func (e Event) AccountStatus() (AccountStatus, error) {
+	switch e.Type {
+	case EventAccountApplicationDeauthorized:
+		return AccountStatus{Account: e.Account, Status: OnboardingDeauthorized}, nil
+
+	case EventAccountUpdated:
+		var a struct {
+			ID               string `json:"id"`
+			ChargesEnabled   bool   `json:"charges_enabled"`
+			PayoutsEnabled   bool   `json:"payouts_enabled"`
+			DetailsSubmitted bool   `json:"details_submitted"`
+			Requirements     struct {
+				CurrentlyDue   []string `json:"currently_due"`
+				DisabledReason string   `json:"disabled_reason"`
+			} `json:"requirements"`
+		}
+		err := json.Unmarshal(e.Data.Raw, &a)
+		if err != nil {
+			return AccountStatus{}, fmt.Errorf("zstripe.Event.AccountStatus: %w", err)
+		}
+
+		s := AccountStatus{
+			Account:          a.ID,
+			ChargesEnabled:   a.ChargesEnabled,
+			PayoutsEnabled:   a.PayoutsEnabled,
+			DetailsSubmitted: a.DetailsSubmitted,
+			CurrentlyDue:     a.Requirements.CurrentlyDue,
+			DisabledReason:   a.Requirements.DisabledReason,
+		}
+		switch {
+		case !s.DetailsSubmitted:
+			s.Status = OnboardingPending
+		case !s.ChargesEnabled || !s.PayoutsEnabled:
+			s.Status = OnboardingRestricted
+		default:
+			s.Status = OnboardingComplete
+		}
+		return s, nil
+
+	default:
+		return AccountStatus{}, fmt.Errorf("zstripe.Event.AccountStatus: not an account event: %q", e.Type)
+	}
+}
+
+// AccountHandler returns an EventHandler which calls fn with the onboarding
+// status for account.updated and account.application.deauthorized events.
+// Other events are ignored.
+func AccountHandler(fn func(AccountStatus) error) EventHandler {
+	return func(e Event) error {
+		if e.Type != EventAccountUpdated && e.Type != EventAccountApplicationDeauthorized {
+			return nil
+		}
+		s, err := e.AccountStatus()
+		if err != nil {
+			return err
+		}
+		return fn(s)
+	}
+}
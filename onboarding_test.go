@@ -0,0 +1,114 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOAuth(t *testing.T) {
+	api := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case r.URL.Path == "/oauth/token" && r.Form.Get("code") == "ac_good":
+			w.Write([]byte(`{"access_token": "sk_xxx", "stripe_user_id": "acct_1", "scope": "read_write"}`))
+		case r.URL.Path == "/oauth/token":
+			w.WriteHeader(400)
+			w.Write([]byte(`{"error": "invalid_grant", "error_description": "Authorization code does not exist"}`))
+		case r.URL.Path == "/oauth/deauthorize" && r.Form.Get("client_id") == "ca_xxx":
+			w.Write([]byte(`{"stripe_user_id": "acct_1"}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.URL, r.Form)
+		}
+	}))
+	defer api.Close()
+	ConnectAPI, ClientID, SecretKey = api.URL, "ca_xxx", "sk_test_xxx"
+	Client.Transport = api.Client().Transport
+	defer func() {
+		ConnectAPI, ClientID, Client.Transport = "https://connect.stripe.com", "", nil
+	}()
+
+	u, state := AuthorizeURL(Body{"client_id": "ca_evil", "redirect_uri": "https://example.com/cb"})
+	pu, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := pu.Query()
+	if pu.Path != "/oauth/authorize" || q.Get("client_id") != "ca_xxx" || q.Get("state") != state ||
+		q.Get("scope") != "read_write" || q.Get("redirect_uri") != "https://example.com/cb" || state == "" {
+		t.Errorf("wrong URL: %s", u)
+	}
+
+	callback := func(qs string) (OAuthToken, error) {
+		return OAuthCallback(httptest.NewRequest("GET", "/cb?"+qs, nil), state)
+	}
+
+	tok, err := callback("code=ac_good&state=" + state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.StripeUserID != "acct_1" {
+		t.Errorf("%#v", tok)
+	}
+
+	_, err = callback("code=ac_good&state=wrong")
+	if !errors.Is(err, ErrOAuthState) {
+		t.Errorf("wrong error: %v", err)
+	}
+	_, err = callback("error=access_denied&state=" + state)
+	if !errors.Is(err, ErrOAuthDenied) {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	_, err = callback("code=ac_bad&state=" + state)
+	var sErr Error
+	if !errors.As(err, &sErr) || sErr.StripeError.Code != "invalid_grant" ||
+		!strings.Contains(err.Error(), "Authorization code does not exist") {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	err = DeauthorizeAccount("acct_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAccountStatus(t *testing.T) {
+	tests := []struct {
+		typ, account, data string
+		want               string
+	}{
+		{EventAccountUpdated, "", `{"id": "acct_1", "details_submitted": false}`, OnboardingPending},
+		{EventAccountUpdated, "", `{"id": "acct_1", "details_submitted": true, "charges_enabled": true,
+			"requirements": {"currently_due": ["external_account"], "disabled_reason": "requirements.past_due"}}`,
+			OnboardingRestricted},
+		{EventAccountUpdated, "", `{"id": "acct_1", "details_submitted": true, "charges_enabled": true,
+			"payouts_enabled": true}`, OnboardingComplete},
+		{EventAccountApplicationDeauthorized, "acct_1", `{"id": "ca_xxx"}`, OnboardingDeauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			e := Event{Type: tt.typ, Account: tt.account}
+			e.Data.Raw = json.RawMessage(tt.data)
+
+			var got AccountStatus
+			err := AccountHandler(func(s AccountStatus) error { got = s; return nil })(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want || got.Account != "acct_1" {
+				t.Errorf("%#v", got)
+			}
+		})
+	}
+
+	_, err := Event{Type: EventCustomerCreated}.AccountStatus()
+	if err == nil {
+		t.Error("no error")
+	}
+}
@@ -0,0 +1,182 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sequencer tracks the last applied event for every object, to detect events
+// that arrive out of order.
+//
+// Stripe doesn't guarantee the order in which events are delivered, so a
+// customer.subscription.updated may arrive after the
+// customer.subscription.deleted for the same subscription. Applying it would
+// "resurrect" the subscription with stale data.
+//
+// The zero value is safe to use. The state is kept in memory only, and grows by
+// one entry for every object; use Expire() to remove old entries.
+type Sequencer struct {
+	// Fetch the current object from the API in Object(), instead of trusting
+	// the event payload.
+	Refetch bool
+
+	mu   sync.Mutex
+	last map[string]applied
+}
+
+type applied struct {
+	created int64
+	deleted bool
+}
+
+// eventObject is the part of data.object that every object has.
+type eventObject struct {
+	ID     string `json:"id"`
+	Object string `json:"object"`
+}
+
+func (e Event) object() (eventObject, error) {
+	var o eventObject
+	err := json.Unmarshal(e.Data.Raw, &o)
+	if err != nil {
+		return o, fmt.Errorf("data.object: %w", err)
+	}
+	if o.ID == "" {
+		return o, fmt.Errorf("data.object: no ID for %q", e.ID)
+	}
+	return o, nil
+}
+
+// Stale reports if an event for the same object that's newer than this one
+// was already applied.
+//
+// Events with the same Created time are not considered stale, as the time only
+// has a granularity of seconds. All events after a *.deleted event for the
+// object are stale, as IDs are never re-used.
+func (s *Sequencer) Stale(e Event) (bool, error) {
+	o, err := e.object()
+	if err != nil {
+		return false, fmt.Errorf("zstripe.Sequencer.Stale: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stale(o.ID, e), nil
+}
+
+// Apply records the event as applied, unless it's stale.
+//
+// This reports if the event was applied; this is done atomically, so it's safe
+// to use from concurrent handlers:
+//
+//	if ok, err := seq.Apply(e); err != nil || !ok {
+//	    return err
+//	}
+func (s *Sequencer) Apply(e Event) (bool, error) {
+	o, err := e.object()
+	if err != nil {
+		return false, fmt.Errorf("zstripe.Sequencer.Apply: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stale(o.ID, e) {
+		return false, nil
+	}
+	if s.last == nil {
+		s.last = make(map[string]applied)
+	}
+	s.last[o.ID] = applied{
+		created: e.Created,
+		deleted: strings.HasSuffix(e.Type, ".deleted"),
+	}
+	return true, nil
+}
+
+// Expire removes objects for which the last applied event is older than age.
+//
+// Events for these objects are never considered stale, so this should be longer
+// than the time Stripe retries events, which is up to three days.
+func (s *Sequencer) Expire(age time.Duration) {
+	before := time.Now().Add(-age).Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, a := range s.last {
+		if a.created < before {
+			delete(s.last, id)
+		}
+	}
+}
+
+func (s *Sequencer) stale(id string, e Event) bool {
+	last, ok := s.last[id]
+	if !ok {
+		return false
+	}
+	return last.deleted || e.Created < last.created
+}
+
+// Object scans the event's object in to scan.
+//
+// If Refetch is set it's retrieved from the API with Request, and the payload
+// is ignored. This only works for top-level objects that can be retrieved with
+// GET /v1/{objects}/{id}, and is never done for *.deleted events as the object
+// no longer exists.
+func (s *Sequencer) Object(e Event, scan interface{}) error {
+	if !s.Refetch || strings.HasSuffix(e.Type, ".deleted") {
+		err := json.Unmarshal(e.Data.Raw, scan)
+		if err != nil {
+			return fmt.Errorf("zstripe.Sequencer.Object: %w", err)
+		}
+		return nil
+	}
+
+	o, err := e.object()
+	if err != nil {
+		return fmt.Errorf("zstripe.Sequencer.Object: %w", err)
+	}
+	path, err := objectPath(o.Object, o.ID)
+	if err != nil {
+		return fmt.Errorf("zstripe.Sequencer.Object: %w", err)
+	}
+	_, err = e.AccountRequest(scan, "GET", path, "")
+	return err
+}
+
+// Objects that can only be retrieved through their parent, e.g.
+// /v1/accounts/{account}/capabilities/{id}; the event doesn't have enough
+// information to build the path.
+var nestedObjects = map[string]bool{
+	"bank_account":                      true,
+	"capability":                        true,
+	"card":                              true,
+	"customer_balance_transaction":      true,
+	"customer_cash_balance_transaction": true,
+	"fee_refund":                        true,
+	"line_item":                         true,
+	"person":                            true,
+	"tax_id":                            true,
+	"transfer_reversal":                 true,
+}
+
+// objectPath gets the API path for an object; e.g. "checkout.session" and
+// "cs_123" becomes /v1/checkout/sessions/cs_123.
+func objectPath(object, id string) (string, error) {
+	if nestedObjects[object] {
+		return "", fmt.Errorf("can't retrieve %s %q: it's nested under another object", object, id)
+	}
+
+	p := strings.ReplaceAll(object, ".", "/")
+	switch {
+	case strings.HasSuffix(p, "y"):
+		p = p[:len(p)-1] + "ies"
+	case strings.HasSuffix(p, "s"):
+		p += "es"
+	default:
+		p += "s"
+	}
+	return "/v1/" + p + "/" + id, nil
+}
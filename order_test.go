@@ -0,0 +1,130 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSequencer(t *testing.T) {
+	ev := func(typ string, created int64) Event {
+		var e Event
+		e.Type, e.Created = typ, created
+		e.Data.Raw = json.RawMessage(`{"id": "sub_1", "object": "subscription", "status": "x"}`)
+		return e
+	}
+
+	tests := []struct {
+		in   Event
+		want bool
+	}{
+		{ev(EventCustomerSubscriptionCreated, 1), true},
+		{ev(EventCustomerSubscriptionUpdated, 3), true},
+		{ev(EventCustomerSubscriptionUpdated, 3), true},
+		{ev(EventCustomerSubscriptionUpdated, 2), false},
+		{ev(EventCustomerSubscriptionDeleted, 4), true},
+		{ev(EventCustomerSubscriptionUpdated, 4), false},
+		{ev(EventCustomerSubscriptionUpdated, 5), false},
+	}
+
+	var s Sequencer
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			stale, err := s.Stale(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stale == tt.want {
+				t.Errorf("Stale() = %t", stale)
+			}
+			ok, err := s.Apply(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("Apply() = %t; want %t", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequencerExpire(t *testing.T) {
+	ev := func(id string, created time.Time) Event {
+		e := Event{Type: EventCustomerDeleted, Created: created.Unix()}
+		e.Data.Raw = json.RawMessage(`{"id": "` + id + `", "object": "customer"}`)
+		return e
+	}
+
+	var s Sequencer
+	for _, e := range []Event{ev("cus_1", time.Now().Add(-2*time.Hour)), ev("cus_2", time.Now())} {
+		if ok, err := s.Apply(e); err != nil || !ok {
+			t.Fatal(ok, err)
+		}
+	}
+	s.Expire(time.Hour)
+
+	for id, want := range map[string]bool{"cus_1": false, "cus_2": true} {
+		stale, err := s.Stale(ev(id, time.Now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stale != want {
+			t.Errorf("%s: %t", id, stale)
+		}
+	}
+}
+
+func TestSequencerObject(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/subscriptions/sub_1" {
+			t.Errorf("wrong path: %q", r.URL.Path)
+		}
+		if r.Header.Get("Stripe-Account") != "acct_1" {
+			w.WriteHeader(404)
+			return
+		}
+		fmt.Fprintln(w, `{"id": "sub_1", "status": "fresh"}`)
+	}))
+	defer api.Close()
+	API = api.URL
+	SecretKey = "sk_test_xxx"
+
+	var e Event
+	e.Type, e.Account = EventCustomerSubscriptionUpdated, "acct_1"
+	e.Data.Raw = json.RawMessage(`{"id": "sub_1", "object": "subscription", "status": "stale"}`)
+
+	for _, refetch := range []bool{false, true} {
+		s := Sequencer{Refetch: refetch}
+		var sub struct {
+			Status string `json:"status"`
+		}
+		err := s.Object(e, &sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[bool]string{false: "stale", true: "fresh"}[refetch]
+		if sub.Status != want {
+			t.Errorf("refetch=%t: %q", refetch, sub.Status)
+		}
+	}
+}
+
+func TestObjectPath(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"customer", "/v1/customers/x"},
+		{"checkout.session", "/v1/checkout/sessions/x"},
+		{"radar.early_fraud_warning", "/v1/radar/early_fraud_warnings/x"},
+		{"capability", ""},
+		{"person", ""},
+	}
+	for _, tt := range tests {
+		got, err := objectPath(tt.in, "x")
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("%q: %q %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package zstripe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors for the Event.Get* methods.
+var (
+	ErrPathMissing = errors.New("zstripe: path doesn't exist")
+	ErrPathType    = errors.New("zstripe: wrong type for path")
+)
+
+// Get the value at path in data.object, as decoded by encoding/json with
+// UseNumber.
+//
+// The path is a list of keys separated by dots, with [n] to index lists; for
+// example "customer_email" or "lines.data[0].price.id". A leading
+// "data.object." is ignored.
+//
+// ErrPathMissing is returned if any part of the path doesn't exist or is null,
+// and ErrPathType if the path tries to index something that's not an object or
+// list.
+func (e Event) Get(path string) (interface{}, error) {
+	return getPath(e.Data.Raw, strings.TrimPrefix(path, "data.object."))
+}
+
+func getPath(raw json.RawMessage, path string) (interface{}, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var v interface{}
+	err = d.Decode(&v)
+	if err != nil {
+		return nil, fmt.Errorf("zstripe.Event.Get: %w", err)
+	}
+
+	for i, k := range keys {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			if k[0] == '[' {
+				return nil, fmt.Errorf("%w %q: %s is an object", ErrPathType, path, joinPath(keys[:i]))
+			}
+			v = vv[k]
+		case []interface{}:
+			if k[0] != '[' {
+				return nil, fmt.Errorf("%w %q: %s is a list", ErrPathType, path, joinPath(keys[:i]))
+			}
+			n, _ := strconv.Atoi(k[1 : len(k)-1])
+			if n >= len(vv) {
+				return nil, fmt.Errorf("%w: %q", ErrPathMissing, path)
+			}
+			v = vv[n]
+		case nil:
+			return nil, fmt.Errorf("%w: %q", ErrPathMissing, path)
+		default:
+			return nil, fmt.Errorf("%w %q: %s is %s", ErrPathType, path, joinPath(keys[:i]), jsonType(v))
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: %q", ErrPathMissing, path)
+	}
+	return v, nil
+}
+
+// GetString gets the string at path; see Get.
+func (e Event) GetString(path string) (string, error) {
+	v, err := e.Get(path)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%w %q: not a string but %s", ErrPathType, path, jsonType(v))
+	}
+	return s, nil
+}
+
+// GetInt gets the integer at path; see Get.
+func (e Event) GetInt(path string) (int64, error) {
+	v, err := e.Get(path)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%w %q: not a number but %s", ErrPathType, path, jsonType(v))
+	}
+	i, err := n.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: not an integer: %s", ErrPathType, path, n)
+	}
+	return i, nil
+}
+
+// GetBool gets the boolean at path; see Get.
+func (e Event) GetBool(path string) (bool, error) {
+	v, err := e.Get(path)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w %q: not a boolean but %s", ErrPathType, path, jsonType(v))
+	}
+	return b, nil
+}
+
+// GetTime gets the timestamp at path, which should be in UNIX seconds like all
+// timestamps in the v1 API; see Get.
+func (e Event) GetTime(path string) (time.Time, error) {
+	n, err := e.GetInt(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(n, 0).UTC(), nil
+}
+
+// splitPath splits "a.b[0].c" in to "a", "b", "[0]", "c".
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, errors.New("zstripe.Event.Get: empty path")
+	}
+
+	var keys []string
+	for _, p := range strings.Split(path, ".") {
+		k := p
+		if i := strings.IndexByte(p, '['); i > -1 {
+			k = p[:i]
+		}
+		if k == "" || strings.IndexByte(k, ']') > -1 {
+			return nil, fmt.Errorf("zstripe.Event.Get: invalid path %q", path)
+		}
+		keys = append(keys, k)
+
+		for p = p[len(k):]; p != ""; {
+			end := strings.IndexByte(p, ']')
+			if p[0] != '[' || end == -1 {
+				return nil, fmt.Errorf("zstripe.Event.Get: invalid path %q", path)
+			}
+			if n, err := strconv.Atoi(p[1:end]); err != nil || n < 0 {
+				return nil, fmt.Errorf("zstripe.Event.Get: invalid index in path %q", path)
+			}
+			keys = append(keys, p[:end+1])
+			p = p[end+1:]
+		}
+	}
+	return keys, nil
+}
+
+func joinPath(keys []string) string {
+	if len(keys) == 0 {
+		return "data.object"
+	}
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 && k[0] != '[' {
+			b.WriteByte('.')
+		}
+		b.WriteString(k)
+	}
+	return b.String()
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,67 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventGet(t *testing.T) {
+	var e Event
+	e.Data.Raw = json.RawMessage(`{
+		"customer_email": "a@example.com",
+		"customer_name": null,
+		"amount_due": 1500,
+		"tax_percent": 21.5,
+		"paid": true,
+		"created": 1622548800,
+		"lines": {"data": [{"price": {"id": "price_1"}}, {"price": {"id": "price_2"}}]}
+	}`)
+
+	str := func(p string) (interface{}, error) { return e.GetString(p) }
+	num := func(p string) (interface{}, error) { return e.GetInt(p) }
+	tests := []struct {
+		get     func(string) (interface{}, error)
+		path    string
+		want    interface{}
+		wantErr error
+	}{
+		{str, "customer_email", "a@example.com", nil},
+		{str, "data.object.customer_email", "a@example.com", nil},
+		{str, "lines.data[1].price.id", "price_2", nil},
+		{str, "lines.data[2].price.id", "", ErrPathMissing},
+		{str, "customer_name", "", ErrPathMissing},
+		{str, "customer_name.first", "", ErrPathMissing},
+		{str, "nope", "", ErrPathMissing},
+		{str, "amount_due", "", ErrPathType},
+		{str, "lines.data.price", "", ErrPathType},
+		{str, "lines[0]", "", ErrPathType},
+		{str, "customer_email.x", "", ErrPathType},
+		{num, "amount_due", int64(1500), nil},
+		{num, "tax_percent", int64(0), ErrPathType},
+		{num, "paid", int64(0), ErrPathType},
+		{func(p string) (interface{}, error) { return e.GetBool(p) }, "paid", true, nil},
+		{func(p string) (interface{}, error) { return e.GetTime(p) }, "created",
+			time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := tt.get(tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("wrong error\ngot:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("\ngot:  %#v\nwant: %#v", got, tt.want)
+			}
+		})
+	}
+
+	for _, p := range []string{"", ".a", "a..b", "a[x]", "a[-1]", "a[0", "a]", "a.[0]"} {
+		_, err := e.Get(p)
+		if err == nil || errors.Is(err, ErrPathMissing) || errors.Is(err, ErrPathType) {
+			t.Errorf("%q: %v", p, err)
+		}
+	}
+}
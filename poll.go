@@ -0,0 +1,242 @@
+package zstripe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CursorStore persists the ID of the last processed event for Poller.
+type CursorStore interface {
+	// Load the cursor; this should return an empty string if there is no
+	// cursor yet.
+	Load() (string, error)
+
+	// Save the cursor.
+	Save(string) error
+}
+
+// FileCursor stores the cursor in a file.
+type FileCursor string
+
+func (f FileCursor) Load() (string, error) {
+	b, err := ioutil.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(b)), err
+}
+
+func (f FileCursor) Save(cursor string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(string(f)), filepath.Base(string(f))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(cursor + "\n")
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// Poller retrieves events from /v1/events, as an alternative to webhooks.
+//
+// Events are passed to the Handler oldest first, fetching 100 at a time. The cursor is saved after
+// every event the Handler processed without error, so events are delivered at
+// least once: if the Handler returns an error the same event is retried on the
+// next poll.
+//
+// Note that Stripe only keeps events for 30 days.
+type Poller struct {
+	Handler EventHandler
+	Cursor  CursorStore
+
+	// Event types to process; the patterns are matched with path.Match, so
+	// "invoice.*" matches all invoice events. All events are processed if this
+	// is empty.
+	//
+	// Events that don't match are skipped, but still advance the cursor.
+	Types []string
+
+	// Poll interval; defaults to 30 seconds.
+	Interval time.Duration
+
+	// Process all events since this time if there is no saved cursor yet. If
+	// this is zero processing starts with the latest event at the first poll.
+	Since time.Time
+
+	// Called for errors in Run(); defaults to printing to stderr.
+	Error func(error)
+}
+
+// Run polls for new events until the context is cancelled.
+func (p Poller) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		err := p.Poll()
+		if err != nil {
+			if p.Error != nil {
+				p.Error(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "zstripe.Poller: %s\n", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// Poll retrieves and processes all new events once.
+func (p Poller) Poll() error {
+	cursor, err := p.Cursor.Load()
+	if err != nil {
+		return fmt.Errorf("zstripe.Poller: loading cursor: %w", err)
+	}
+
+	if cursor == "" {
+		cursor, err = p.start()
+		if err != nil || cursor == "" {
+			return err
+		}
+	}
+
+	for {
+		var page struct {
+			Data    []Event `json:"data"`
+			HasMore bool    `json:"has_more"`
+		}
+		_, err := Request(&page, "GET", "/v1/events?"+Body{
+			"ending_before": cursor,
+			"limit":         "100",
+		}.Encode(), "")
+		if err != nil {
+			return fmt.Errorf("zstripe.Poller: %w", err)
+		}
+
+		// ending_before gives us the page right after the cursor, but still in
+		// newest-first order.
+		for i := len(page.Data) - 1; i >= 0; i-- {
+			err := p.dispatch(page.Data[i])
+			if err != nil {
+				return err
+			}
+			cursor = page.Data[i].ID
+		}
+		if !page.HasMore || len(page.Data) == 0 {
+			return nil
+		}
+	}
+}
+
+// start gets the cursor to start from if there's no saved cursor yet.
+func (p Poller) start() (string, error) {
+	// Start from the latest event, which is processed.
+	if p.Since.IsZero() {
+		e, err := latestEvent(Body{})
+		if err != nil || e == nil {
+			return "", err
+		}
+		return e.ID, p.dispatch(*e)
+	}
+
+	// Start after the latest event before Since.
+	since := strconv.FormatInt(p.Since.Unix(), 10)
+	e, err := latestEvent(Body{"created[lt]": since})
+	if err != nil {
+		return "", err
+	}
+	if e != nil {
+		err := p.Cursor.Save(e.ID)
+		if err != nil {
+			return "", fmt.Errorf("zstripe.Poller: saving cursor: %w", err)
+		}
+		return e.ID, nil
+	}
+
+	// There are no events before Since (e.g. because Stripe deleted them), so
+	// start from the oldest event since then. Events are listed newest first,
+	// so we need to list all of them to find it; only the oldest one is kept,
+	// and the rest are processed by paging forward from it.
+	var oldest *Event
+	err = List("/v1/events", Body{"created[gte]": since, "limit": "100"}, func(o json.RawMessage) error {
+		oldest = new(Event)
+		return json.Unmarshal(o, oldest)
+	})
+	if err != nil {
+		return "", fmt.Errorf("zstripe.Poller: backfill: %w", err)
+	}
+	if oldest == nil {
+		return "", nil
+	}
+	return oldest.ID, p.dispatch(*oldest)
+}
+
+// latestEvent gets the latest event, or nil if there are no events.
+func latestEvent(params Body) (*Event, error) {
+	var page struct {
+		Data []Event `json:"data"`
+	}
+	params["limit"] = "1"
+	_, err := Request(&page, "GET", "/v1/events?"+params.Encode(), "")
+	if err != nil {
+		return nil, fmt.Errorf("zstripe.Poller: %w", err)
+	}
+	if len(page.Data) == 0 {
+		return nil, nil
+	}
+	return &page.Data[0], nil
+}
+
+func (p Poller) dispatch(e Event) error {
+	if matchType(p.Types, e.Type) {
+		err := p.Handler(e)
+		if err != nil {
+			return fmt.Errorf("zstripe.Poller: handler for %s: %w", e.ID, err)
+		}
+	}
+
+	err := p.Cursor.Save(e.ID)
+	if err != nil {
+		return fmt.Errorf("zstripe.Poller: saving cursor: %w", err)
+	}
+	return nil
+}
+
+// matchType reports if the event type matches any of the patterns, or if there
+// are no patterns.
+func matchType(patterns []string, typ string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, typ); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeEvents serves /v1/events for the events, which must be newest first.
+func fakeEvents(t *testing.T, events []Event) {
+	t.Helper()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if limit == 0 {
+			limit = 10
+		}
+		gte, _ := strconv.ParseInt(q.Get("created[gte]"), 10, 64)
+		lte, _ := strconv.ParseInt(q.Get("created[lte]"), 10, 64)
+		lt, _ := strconv.ParseInt(q.Get("created[lt]"), 10, 64)
+
+		var match []Event
+		for _, e := range events {
+			if e.Created < gte || (lte > 0 && e.Created > lte) || (lt > 0 && e.Created >= lt) {
+				continue
+			}
+			if q.Get("type") != "" && !matchType([]string{q.Get("type")}, e.Type) {
+				continue
+			}
+			match = append(match, e)
+		}
+
+		start, end := 0, len(match)
+		for i, e := range match {
+			if e.ID == q.Get("starting_after") {
+				start = i + 1
+			}
+			if e.ID == q.Get("ending_before") {
+				end = i
+			}
+		}
+		page := match[start:end]
+		hasMore := false
+		if len(page) > limit {
+			hasMore = true
+			if q.Get("ending_before") != "" {
+				page = page[len(page)-limit:]
+			} else {
+				page = page[:limit]
+			}
+		}
+
+		j, _ := json.Marshal(map[string]interface{}{"data": page, "has_more": hasMore})
+		w.Write(j)
+	}))
+	t.Cleanup(api.Close)
+	API = api.URL
+	SecretKey = "sk_test_xxx"
+}
+
+func makeEvents(n int) []Event {
+	events := make([]Event, n)
+	for i := range events {
+		events[i].ID = fmt.Sprintf("evt_%d", n-i)
+		events[i].Created = int64(n - i)
+		events[i].Type = EventCustomerUpdated
+		if i%2 == 0 {
+			events[i].Type = EventInvoicePaid
+		}
+	}
+	return events
+}
+
+func TestPoller(t *testing.T) {
+	events := makeEvents(250)
+	fakeEvents(t, events)
+
+	var (
+		got    []string
+		fail   = errors.New("oh noes")
+		failed bool
+	)
+	p := Poller{
+		Cursor: FileCursor(filepath.Join(t.TempDir(), "cursor")),
+		Types:  []string{"invoice.*"},
+		Handler: func(e Event) error {
+			if e.ID == "evt_200" && !failed {
+				failed = true
+				got = append(got, "fail")
+				return fail
+			}
+			got = append(got, e.ID)
+			return nil
+		},
+	}
+
+	// Start from the latest event, which is processed.
+	err := p.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := p.Cursor.Load(); c != "evt_250" {
+		t.Fatalf("cursor: %q", c)
+	}
+
+	// Process everything from evt_1.
+	p.Cursor.Save("evt_1")
+	err = p.Poll()
+	if !errors.Is(err, fail) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if c, _ := p.Cursor.Load(); c != "evt_199" {
+		t.Fatalf("cursor: %q", c)
+	}
+	err = p.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"evt_250"}
+	for i := 2; i <= 250; i += 2 {
+		if i == 200 {
+			want = append(want, "fail")
+		}
+		want = append(want, fmt.Sprintf("evt_%d", i))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v", got, want)
+	}
+	if c, _ := p.Cursor.Load(); c != "evt_250" {
+		t.Fatalf("cursor: %q", c)
+	}
+}
+
+func TestPollerBackfill(t *testing.T) {
+	fakeEvents(t, makeEvents(250))
+
+	tests := []struct {
+		since       int64
+		first, last string
+		n           int
+	}{
+		{140, "evt_140", "evt_250", 111},
+		// No events before Since.
+		{1, "evt_1", "evt_250", 250},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.since), func(t *testing.T) {
+			var got []string
+			p := Poller{
+				Cursor: FileCursor(filepath.Join(t.TempDir(), "cursor")),
+				Since:  time.Unix(tt.since, 0),
+				Handler: func(e Event) error {
+					got = append(got, e.ID)
+					return nil
+				},
+			}
+
+			err := p.Poll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.n || got[0] != tt.first || got[len(got)-1] != tt.last {
+				t.Errorf("%d events: %v", len(got), got)
+			}
+			if c, _ := p.Cursor.Load(); c != tt.last {
+				t.Fatalf("cursor: %q", c)
+			}
+		})
+	}
+}
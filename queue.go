@@ -0,0 +1,216 @@
+package zstripe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Possible errors from Queue.Enqueue.
+var (
+	ErrQueueFull   = errors.New("zstripe.Queue: queue is full")
+	ErrQueueClosed = errors.New("zstripe.Queue: queue is shut down")
+)
+
+// Queue processes webhook events asynchronously with a pool of workers.
+//
+// Stripe expects a quick response to webhooks, and will retry the delivery if
+// it takes too long. Queue responds as soon as the event is verified and
+// queued, and processes it in the background.
+//
+// Events with the same key (see Key) are always processed by the same worker,
+// in the order they were received, so all events for one customer are
+// processed serially.
+//
+// Events are only kept in memory; they are lost if the process crashes.
+type Queue struct {
+	Handler EventHandler
+
+	Workers int // Number of workers; defaults to 4.
+	Size    int // Number of events to buffer per worker; defaults to 100.
+
+	// Number of times to retry the Handler on errors, and the delay before the
+	// first retry. The delay is doubled on every retry. Defaults to 3 and 1
+	// second; set Retries to a negative value to never retry.
+	Retries int
+	Backoff time.Duration
+
+	// Key to order events by; events with the same key are processed serially.
+	// The default is data.object.customer, or data.object.id if the object has
+	// no customer.
+	Key func(Event) string
+
+	// Called if the Handler still fails after all retries; defaults to
+	// printing to stderr.
+	Error func(Event, error)
+
+	once   sync.Once
+	mu     sync.RWMutex
+	closed bool
+	queues []chan Event
+	wg     sync.WaitGroup
+}
+
+func (q *Queue) start() {
+	q.once.Do(func() {
+		if q.Workers == 0 {
+			q.Workers = 4
+		}
+		if q.Size == 0 {
+			q.Size = 100
+		}
+		switch {
+		case q.Retries == 0:
+			q.Retries = 3
+		case q.Retries < 0:
+			q.Retries = 0
+		}
+		if q.Backoff == 0 {
+			q.Backoff = time.Second
+		}
+		if q.Key == nil {
+			q.Key = defaultKey
+		}
+
+		q.queues = make([]chan Event, q.Workers)
+		for i := range q.queues {
+			q.queues[i] = make(chan Event, q.Size)
+			q.wg.Add(1)
+			go q.work(q.queues[i])
+		}
+	})
+}
+
+func defaultKey(e Event) string {
+	var o struct {
+		ID       string          `json:"id"`
+		Customer json.RawMessage `json:"customer"`
+	}
+	_ = json.Unmarshal(e.Data.Raw, &o)
+
+	if cus := expandableID(o.Customer); cus != "" {
+		return cus
+	}
+	if o.ID != "" {
+		return o.ID
+	}
+	return e.ID
+}
+
+// expandableID gets the ID from a field that's either an ID or an object if
+// it's been expanded.
+func expandableID(b json.RawMessage) string {
+	var id string
+	if json.Unmarshal(b, &id) == nil && id != "" {
+		return id
+	}
+	var obj ID
+	if json.Unmarshal(b, &obj) == nil {
+		return obj.ID
+	}
+	return ""
+}
+
+func (q *Queue) work(ch chan Event) {
+	defer q.wg.Done()
+	for e := range ch {
+		var (
+			err   error
+			delay = q.Backoff
+		)
+		for i := 0; i <= q.Retries; i++ {
+			if i > 0 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+			err = q.Handler(e)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			if q.Error != nil {
+				q.Error(e, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "zstripe.Queue: handling %s: %s\n", e.ID, err)
+			}
+		}
+	}
+}
+
+// Enqueue adds the event to the queue.
+//
+// This returns ErrQueueFull if the worker for this event has no room in its
+// buffer, or ErrQueueClosed after Shutdown().
+func (q *Queue) Enqueue(e Event) error {
+	q.start()
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(q.Key(e)))
+	select {
+	case q.queues[h.Sum32()%uint32(len(q.queues))] <- e:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// ServeHTTP reads the event with Event.Read and adds it to the queue.
+//
+// This responds with 400 Bad Request if the event can't be read or verified,
+// and 503 Service Unavailable if the event can't be queued, so Stripe will
+// retry it later.
+func (q *Queue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var e Event
+	err := e.Read(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = q.Enqueue(e)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Shutdown stops accepting new events, and waits for all queued events to be
+// processed or until the context is cancelled.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.start()
+
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		for _, ch := range q.queues {
+			close(ch)
+		}
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("zstripe.Queue.Shutdown: %w", ctx.Err())
+	}
+}
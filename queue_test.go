@@ -0,0 +1,132 @@
+package zstripe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		got      = make(map[string][]string)
+		attempts = make(map[string]int)
+		failed   []string
+	)
+	q := &Queue{
+		Workers: 3,
+		Backoff: time.Millisecond,
+		Retries: 2,
+		Handler: func(e Event) error {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[e.ID]++
+			if e.ID == "evt_fail" || (e.ID == "evt_retry" && attempts[e.ID] < 3) {
+				return errors.New("oh noes")
+			}
+			cus := defaultKey(e)
+			got[cus] = append(got[cus], e.ID)
+			return nil
+		},
+		Error: func(e Event, err error) { failed = append(failed, e.ID) },
+	}
+
+	ev := func(id, cus string) Event {
+		e := Event{ID: id}
+		e.Data.Raw = json.RawMessage(fmt.Sprintf(`{"id": "in_%s", "customer": %q}`, id, cus))
+		return e
+	}
+	for i := 0; i < 20; i++ {
+		for _, cus := range []string{"cus_1", "cus_2", "cus_3"} {
+			err := q.Enqueue(ev(fmt.Sprintf("evt_%s_%d", cus, i), cus))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	q.Enqueue(ev("evt_fail", "cus_4"))
+	q.Enqueue(ev("evt_retry", "cus_4"))
+
+	err := q.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue(ev("evt_x", "cus_1")); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	for _, cus := range []string{"cus_1", "cus_2", "cus_3"} {
+		if len(got[cus]) != 20 {
+			t.Fatalf("%s: %v", cus, got[cus])
+		}
+		for i, id := range got[cus] {
+			if want := fmt.Sprintf("evt_%s_%d", cus, i); id != want {
+				t.Errorf("%s: out of order: %v", cus, got[cus])
+				break
+			}
+		}
+	}
+	if attempts["evt_fail"] != 3 || len(failed) != 1 || failed[0] != "evt_fail" {
+		t.Errorf("attempts=%d; failed=%v", attempts["evt_fail"], failed)
+	}
+	if len(got["cus_4"]) != 1 || got["cus_4"][0] != "evt_retry" {
+		t.Errorf("cus_4: %v", got["cus_4"])
+	}
+}
+
+func TestQueueNoRetries(t *testing.T) {
+	var attempts, failed int
+	q := &Queue{
+		Retries: -1,
+		Handler: func(Event) error { attempts++; return errors.New("oh noes") },
+		Error:   func(Event, error) { failed++ },
+	}
+	if err := q.Enqueue(Event{ID: "evt_1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 1 || failed != 1 {
+		t.Errorf("attempts=%d failed=%d", attempts, failed)
+	}
+}
+
+func TestQueueHTTP(t *testing.T) {
+	SignSecret = "testing"
+	defer func() { SignSecret = "" }()
+
+	var (
+		block   = make(chan struct{})
+		started = make(chan struct{}, 3)
+	)
+	q := &Queue{Workers: 1, Size: 1, Handler: func(Event) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}}
+
+	var codes []int
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		q.ServeHTTP(rr, httptest.NewRequest("POST", "/", strings.NewReader(`{"id": "evt_1"}`)))
+		codes = append(codes, rr.Code)
+		if i == 0 {
+			<-started
+		}
+	}
+	close(block)
+	q.Shutdown(context.Background())
+
+	// First is picked up by the worker, second is buffered, third doesn't fit.
+	if fmt.Sprint(codes) != "[200 200 503]" {
+		t.Errorf("%v", codes)
+	}
+}
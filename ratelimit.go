@@ -0,0 +1,207 @@
+package zstripe
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a http.RoundTripper which limits the rate of requests with a
+// token bucket, and the number of concurrent requests.
+//
+// Use it by setting it as the Transport for Client:
+//
+//	zstripe.Client.Transport = &zstripe.RateLimiter{}
+//
+// Reads (GET and HEAD) and writes have separate budgets, and live and test mode
+// have different defaults, following Stripe's documented limits. Requests are
+// in live mode if the Authorization header has a sk_live_ or rk_live_ key, and
+// in test mode otherwise.
+//
+// The rate is halved every time Stripe responds with 429 Too Many Requests,
+// and slowly increased again for every successful request.
+type RateLimiter struct {
+	// Transport to use; defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// Requests per second for live and test mode. Defaults to 100 for live
+	// mode and 25 for test mode.
+	LiveRead, LiveWrite float64
+	TestRead, TestWrite float64
+
+	// Maximum number of requests in flight; 0 means no limit. A request is in
+	// flight until its response body is closed.
+	Concurrency int
+
+	once    sync.Once
+	sem     chan struct{}
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+type bucketKey struct{ live, write bool }
+
+type bucket struct {
+	rate   float64 // Configured rate.
+	factor float64 // Adaptive slowdown; between 0 and 1.
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) effective() float64 {
+	r := b.rate * b.factor
+	if r < 1 {
+		return 1
+	}
+	return r
+}
+
+// reserve a token, returning how long to wait for it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	r := b.effective()
+	b.tokens += now.Sub(b.last).Seconds() * r
+	if b.tokens > r {
+		b.tokens = r
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / r * float64(time.Second))
+}
+
+func (rl *RateLimiter) start() {
+	rl.once.Do(func() {
+		if rl.Concurrency > 0 {
+			rl.sem = make(chan struct{}, rl.Concurrency)
+		}
+		rl.buckets = make(map[bucketKey]*bucket)
+	})
+}
+
+func (rl *RateLimiter) bucket(k bucketKey) *bucket {
+	b, ok := rl.buckets[k]
+	if ok {
+		return b
+	}
+
+	var rate float64
+	switch {
+	case k.live && k.write:
+		rate = rl.LiveWrite
+	case k.live:
+		rate = rl.LiveRead
+	case k.write:
+		rate = rl.TestWrite
+	default:
+		rate = rl.TestRead
+	}
+	if rate == 0 {
+		rate = 25
+		if k.live {
+			rate = 100
+		}
+	}
+
+	// Start with a full bucket.
+	b = &bucket{rate: rate, factor: 1, tokens: rate, last: time.Now()}
+	rl.buckets[k] = b
+	return b
+}
+
+func (rl *RateLimiter) RoundTrip(r *http.Request) (*http.Response, error) {
+	rl.start()
+
+	k := bucketKey{
+		live:  liveKey(r.Header.Get("Authorization")),
+		write: r.Method != "GET" && r.Method != "HEAD",
+	}
+
+	rl.mu.Lock()
+	wait := rl.bucket(k).reserve(time.Now())
+	rl.mu.Unlock()
+	if wait > 0 {
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return nil, r.Context().Err()
+		}
+	}
+
+	if rl.sem != nil {
+		select {
+		case rl.sem <- struct{}{}:
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		}
+	}
+
+	tr := rl.Transport
+	if tr == nil {
+		tr = http.DefaultTransport
+	}
+	resp, err := tr.RoundTrip(r)
+	if err != nil {
+		rl.release()
+		return nil, err
+	}
+
+	rl.mu.Lock()
+	b := rl.bucket(k)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		b.factor /= 2
+		if b.factor < 1/b.rate {
+			b.factor = 1 / b.rate
+		}
+	} else if b.factor < 1 {
+		b.factor *= 1.05
+		if b.factor > 1 {
+			b.factor = 1
+		}
+	}
+	rl.mu.Unlock()
+
+	if rl.sem != nil {
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: rl.release}
+	}
+	return resp, nil
+}
+
+// liveKey reports if the Authorization header has a live mode key.
+func liveKey(auth string) bool {
+	key := strings.TrimPrefix(auth, "Bearer ")
+	return strings.HasPrefix(key, "sk_live_") || strings.HasPrefix(key, "rk_live_")
+}
+
+// Rate gets the current rate for live or test mode reads or writes, in
+// requests per second.
+func (rl *RateLimiter) Rate(live, write bool) float64 {
+	rl.start()
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.bucket(bucketKey{live, write}).effective()
+}
+
+func (rl *RateLimiter) release() {
+	if rl.sem != nil {
+		<-rl.sem
+	}
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
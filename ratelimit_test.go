@@ -0,0 +1,138 @@
+package zstripe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var (
+		inFlight, maxInFlight int32
+		tooMany               int32
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if atomic.LoadInt32(&tooMany) > 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	rl := &RateLimiter{TestWrite: 20, Concurrency: 2}
+	Client.Transport = rl
+	defer func() { Client.Transport = nil }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Request(nil, "POST", "/v1/customers", "")
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight != 2 {
+		t.Errorf("maxInFlight = %d", maxInFlight)
+	}
+	if r := rl.Rate(false, false); r != 25 {
+		t.Errorf("read rate = %f", r)
+	}
+
+	atomic.StoreInt32(&tooMany, 1)
+	for i := 0; i < 2; i++ {
+		_, err := Request(nil, "POST", "/v1/customers", "")
+		if !errorContains(err, "429") {
+			t.Errorf("wrong error: %v", err)
+		}
+	}
+	if r := rl.Rate(false, true); r != 5 {
+		t.Errorf("rate after 429 = %f", r)
+	}
+
+	atomic.StoreInt32(&tooMany, 0)
+	_, err := Request(nil, "POST", "/v1/customers", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rl.Rate(false, true); r != 5*1.05 {
+		t.Errorf("rate after recovery = %f", r)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	var (
+		start = time.Unix(0, 0)
+		b     = &bucket{rate: 20, factor: 1, tokens: 20, last: start}
+	)
+	// 20 requests fit in the bucket, and then it's 50ms per request.
+	for i := 0; i < 20; i++ {
+		if got := b.reserve(start); got != 0 {
+			t.Fatalf("%d: %s", i, got)
+		}
+	}
+	tests := []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{0, 50 * time.Millisecond},
+		{0, 100 * time.Millisecond},
+		{100 * time.Millisecond, 50 * time.Millisecond},
+
+		// Refilled, but not more than the rate.
+		{10 * time.Second, 0},
+	}
+	for i, tt := range tests {
+		if got := b.reserve(start.Add(tt.at)); got != tt.want {
+			t.Errorf("%d at %s: %s; want %s", i, tt.at, got, tt.want)
+		}
+	}
+	if b.tokens != 19 {
+		t.Errorf("tokens: %f", b.tokens)
+	}
+
+	// Halved rate.
+	b.factor = 0.5
+	if got := b.reserve(start.Add(10 * time.Second)); got != 0 {
+		t.Errorf("%s", got)
+	}
+	b.tokens = 0
+	if got := b.reserve(start.Add(10 * time.Second)); got != 100*time.Millisecond {
+		t.Errorf("%s", got)
+	}
+}
+
+func TestLiveKey(t *testing.T) {
+	tests := map[string]bool{
+		"Bearer sk_live_xxx": true,
+		"Bearer rk_live_xxx": true,
+		"Bearer sk_test_xxx": false,
+		"Bearer rk_test_xxx": false,
+		"Bearer pk_live_xxx": false,
+		"Bearer sk_xxx":      false,
+		"":                   false,
+	}
+	for auth, want := range tests {
+		if got := liveKey(auth); got != want {
+			t.Errorf("%q: %t", auth, got)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Records are the local records to reconcile with Stripe.
+type Records interface {
+	// IDs gets the Stripe IDs of all local records.
+	//
+	// This must return the same set of objects as the list endpoint with the
+	// Reconciler's Params; for example if Params has status=active then this
+	// should return only records for active objects, or all others will be
+	// reported as Extra.
+	IDs() ([]string, error)
+
+	// Record gets the local record for a Stripe ID, as a map of paths to
+	// values; ok is false if there is no local record.
+	//
+	// Only the paths in the map are compared. Paths use the same syntax as
+	// Event.Get, e.g. "email" or "items.data[0].price.id".
+	Record(id string) (fields map[string]interface{}, ok bool, err error)
+}
+
+// Reconciler compares local records with objects in Stripe.
+type Reconciler struct {
+	Path    string // List endpoint, e.g. "/v1/customers".
+	Params  Body   // Extra parameters for the list endpoint; also see Records.IDs.
+	Records Records
+
+	// If set, this is called with a synthetic *.created event for missing
+	// objects and an *.updated event for differing objects, so the handlers
+	// you already have for webhooks can fix the local records.
+	//
+	// The events have an ID starting with "evt_reconcile_" and no
+	// previous_attributes. Extra records can't be healed, as there is no object
+	// in Stripe.
+	Heal EventHandler
+}
+
+// ReconcileReport is the result of Reconciler.Run.
+type ReconcileReport struct {
+	Missing []string // In Stripe, but not in the local records.
+	Extra   []string // In the local records, but not in Stripe.
+	Differ  []ReconcileDiff
+	Healed  int // Number of events sent to Heal without errors.
+}
+
+// ReconcileDiff is an object that differs between the local records and
+// Stripe; Old is the local value and New the value in Stripe.
+type ReconcileDiff struct {
+	ID     string
+	Fields []Change
+}
+
+// OK reports if there are no differences.
+func (r ReconcileReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Differ) == 0
+}
+
+// Run the reconciliation.
+//
+// Errors from Heal don't stop the run; they're returned combined after all
+// objects are compared.
+func (r Reconciler) Run() (ReconcileReport, error) {
+	var (
+		report  ReconcileReport
+		seen    = make(map[string]bool)
+		healErr []error
+	)
+
+	heal := func(o json.RawMessage, id, action string) {
+		if r.Heal == nil {
+			return
+		}
+		e, err := syntheticEvent(o, action)
+		if err == nil {
+			err = r.Heal(e)
+		}
+		if err != nil {
+			healErr = append(healErr, fmt.Errorf("%s: %w", id, err))
+			return
+		}
+		report.Healed++
+	}
+
+	params := Body{"limit": "100"}
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	err := List(r.Path, params, func(o json.RawMessage) error {
+		var obj ID
+		err := json.Unmarshal(o, &obj)
+		if err != nil {
+			return err
+		}
+		seen[obj.ID] = true
+
+		fields, ok, err := r.Records.Record(obj.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.Missing = append(report.Missing, obj.ID)
+			heal(o, obj.ID, "created")
+			return nil
+		}
+
+		var d []Change
+		for path, want := range fields {
+			have, err := getPath(o, path)
+			if err != nil && !errors.Is(err, ErrPathMissing) && !errors.Is(err, ErrPathType) {
+				return err
+			}
+			if !sameJSON(want, have) {
+				d = append(d, Change{Path: path, Old: want, New: have})
+			}
+		}
+		if len(d) > 0 {
+			sort.Slice(d, func(i, j int) bool { return d[i].Path < d[j].Path })
+			report.Differ = append(report.Differ, ReconcileDiff{ID: obj.ID, Fields: d})
+			heal(o, obj.ID, "updated")
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("zstripe.Reconciler.Run: %w", err)
+	}
+
+	ids, err := r.Records.IDs()
+	if err != nil {
+		return report, fmt.Errorf("zstripe.Reconciler.Run: %w", err)
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			report.Extra = append(report.Extra, id)
+		}
+	}
+	sort.Strings(report.Extra)
+
+	if len(healErr) > 0 {
+		return report, fmt.Errorf("zstripe.Reconciler.Run: %d heal errors; first: %w", len(healErr), healErr[0])
+	}
+	return report, nil
+}
+
+// syntheticEvent creates an event for the object, as if it was sent by
+// Stripe. This is an error if Stripe has no such event for the object type.
+func syntheticEvent(o json.RawMessage, action string) (Event, error) {
+	var obj struct {
+		ID       string `json:"id"`
+		Object   string `json:"object"`
+		Livemode bool   `json:"livemode"`
+	}
+	err := json.Unmarshal(o, &obj)
+	if err != nil {
+		return Event{}, err
+	}
+
+	var typ string
+	for _, et := range EventTypes {
+		if et.Object == obj.Object && et.Action == action && !et.Deprecated {
+			typ = et.Type
+			break
+		}
+	}
+	if typ == "" {
+		return Event{}, fmt.Errorf("no %s event for %q objects", action, obj.Object)
+	}
+
+	now := time.Now()
+	e := Event{
+		ID:       fmt.Sprintf("evt_reconcile_%s_%d", obj.ID, now.UnixNano()),
+		Object:   "event",
+		Type:     typ,
+		Livemode: obj.Livemode,
+		Created:  now.Unix(),
+	}
+	e.Data.Raw = o
+	return e, nil
+}
@@ -0,0 +1,87 @@
+package zstripe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testRecords map[string]map[string]interface{}
+
+func (r testRecords) IDs() ([]string, error) {
+	ids := make([]string, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r testRecords) Record(id string) (map[string]interface{}, bool, error) {
+	f, ok := r[id]
+	return f, ok, nil
+}
+
+func TestReconciler(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/customers" || r.URL.Query().Get("limit") != "100" {
+			t.Errorf("wrong URL: %s", r.URL)
+		}
+		w.Write([]byte(`{"has_more": false, "data": [
+			{"id": "cus_1", "object": "customer", "email": "a@example.com", "balance": 0},
+			{"id": "cus_2", "object": "customer", "email": "new@example.com", "balance": 100,
+			 "metadata": {"plan": "pro"}},
+			{"id": "cus_3", "object": "customer", "email": "c@example.com", "balance": 0}
+		]}`))
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	var healed []string
+	rec := Reconciler{
+		Path: "/v1/customers",
+		Records: testRecords{
+			"cus_1": {"email": "a@example.com", "balance": 0},
+			"cus_2": {"email": "old@example.com", "balance": 100, "metadata.plan": "basic", "name": nil},
+			"cus_4": {"email": "d@example.com"},
+		},
+		Heal: func(e Event) error {
+			id, _ := e.GetString("id")
+			healed = append(healed, e.Type+" "+id)
+			return nil
+		},
+	}
+
+	report, err := rec.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Error("OK")
+	}
+
+	got := fmt.Sprintf("missing=%v extra=%v differ=%v healed=%d %v",
+		report.Missing, report.Extra, report.Differ, report.Healed, healed)
+	want := "missing=[cus_3] extra=[cus_4] " +
+		"differ=[{cus_2 [email: old@example.com → new@example.com metadata.plan: basic → pro]}] " +
+		"healed=2 [customer.updated cus_2 customer.created cus_3]"
+	if got != want {
+		t.Errorf("\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestSyntheticEvent(t *testing.T) {
+	e, err := syntheticEvent([]byte(`{"id": "in_1", "object": "invoice", "livemode": true}`), "updated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != EventInvoiceUpdated || !e.Livemode || !strings.HasPrefix(e.ID, "evt_reconcile_in_1_") {
+		t.Errorf("%+v", e)
+	}
+
+	_, err = syntheticEvent([]byte(`{"id": "txn_1", "object": "balance_transaction"}`), "created")
+	if err == nil {
+		t.Error("no error for unknown event type")
+	}
+}
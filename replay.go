@@ -0,0 +1,172 @@
+package zstripe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Replay re-delivers events from /v1/events, for example to recover from
+// webhook downtime.
+//
+// Events are signed with SignSecret and sent to either URL or Handler, oldest
+// first, exactly as Stripe would send them.
+type Replay struct {
+	From, To time.Time // Time window; To may be zero for "until now".
+
+	// Event types to replay; the patterns are matched with path.Match, so
+	// "invoice.*" matches all invoice events. All events are replayed if this
+	// is empty.
+	Types []string
+
+	// Skip events with EventDone in this store. May be nil.
+	Store EventStore
+
+	URL     string       // POST events to this URL.
+	Handler http.Handler // Or deliver them to this handler.
+}
+
+// ReplayResult is the result of a replay.
+type ReplayResult struct {
+	Delivered []string         // Event IDs delivered successfully.
+	Skipped   []string         // Event IDs already processed according to the store.
+	Failed    map[string]error // Event IDs for which the delivery failed.
+}
+
+// Run the replay.
+//
+// The returned error is only for errors listing the events; a failed delivery
+// of an event is recorded in ReplayResult.Failed, and doesn't stop the replay.
+func (r Replay) Run() (ReplayResult, error) {
+	if r.URL == "" && r.Handler == nil {
+		panic("zstripe.Replay.Run: must set URL or Handler")
+	}
+
+	params := Body{
+		"created[gte]": strconv.FormatInt(r.From.Unix(), 10),
+		"limit":        "100",
+	}
+	if !r.To.IsZero() {
+		params["created[lte]"] = strconv.FormatInt(r.To.Unix(), 10)
+	}
+	if len(r.Types) == 1 {
+		params["type"] = r.Types[0]
+	}
+
+	var events []json.RawMessage
+	err := List("/v1/events", params, func(o json.RawMessage) error {
+		events = append(events, o)
+		return nil
+	})
+	if err != nil {
+		return ReplayResult{}, fmt.Errorf("zstripe.Replay.Run: %w", err)
+	}
+
+	res := ReplayResult{Failed: make(map[string]error)}
+	for i := len(events) - 1; i >= 0; i-- {
+		var e Event
+		err := json.Unmarshal(events[i], &e)
+		if err != nil {
+			return res, fmt.Errorf("zstripe.Replay.Run: %w", err)
+		}
+		if !matchType(r.Types, e.Type) {
+			continue
+		}
+
+		if r.Store != nil {
+			status, err := r.Store.Status(e.ID)
+			if err != nil {
+				return res, fmt.Errorf("zstripe.Replay.Run: %w", err)
+			}
+			if status == EventDone {
+				res.Skipped = append(res.Skipped, e.ID)
+				continue
+			}
+		}
+
+		err = r.deliver(events[i])
+		if err != nil {
+			res.Failed[e.ID] = err
+			continue
+		}
+		res.Delivered = append(res.Delivered, e.ID)
+	}
+	return res, nil
+}
+
+func (r Replay) deliver(payload []byte) error {
+	var (
+		url = r.URL
+		sig = Sign(payload, time.Now())
+	)
+	if url == "" {
+		url = "/"
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Stripe-Signature", sig)
+
+	var (
+		code int
+		body io.Reader
+	)
+	if r.Handler != nil {
+		rec := &responseRecorder{code: http.StatusOK}
+		r.Handler.ServeHTTP(rec, req)
+		code, body = rec.code, &rec.body
+	} else {
+		resp, err := Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		code, body = resp.StatusCode, resp.Body
+	}
+
+	if code >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(body, 512))
+		return fmt.Errorf("status %d: %s", code, bytes.TrimSpace(b))
+	}
+	return nil
+}
+
+// responseRecorder is a http.ResponseWriter that records the status code and
+// the start of the body.
+type responseRecorder struct {
+	header      http.Header
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *responseRecorder) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+	return w.header
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code, w.wroteHeader = code, true
+	}
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if n := 512 - w.body.Len(); n > 0 {
+		if len(b) < n {
+			n = len(b)
+		}
+		w.body.Write(b[:n])
+	}
+	return len(b), nil
+}
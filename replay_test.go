@@ -0,0 +1,53 @@
+package zstripe
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	fakeEvents(t, makeEvents(20))
+	SignSecret = "whsec_test"
+	defer func() { SignSecret = "" }()
+
+	var got []string
+	r := Replay{
+		From:  time.Unix(5, 0),
+		To:    time.Unix(12, 0),
+		Types: []string{EventInvoicePaid},
+		Store: &MemoryStore{},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var e Event
+			err := e.Read(r)
+			if err != nil {
+				w.WriteHeader(400)
+				return
+			}
+			if e.ID == "evt_10" {
+				w.WriteHeader(500)
+				return
+			}
+			got = append(got, e.ID)
+		}),
+	}
+	r.Store.Claim("evt_8", time.Now(), time.Now())
+	r.Store.Finish("evt_8", EventDone, time.Now())
+
+	res, err := r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"evt_6", "evt_12"}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(res.Delivered, want) {
+		t.Errorf("\ngot:       %v\ndelivered: %v\nwant:      %v", got, res.Delivered, want)
+	}
+	if !reflect.DeepEqual(res.Skipped, []string{"evt_8"}) {
+		t.Errorf("skipped: %v", res.Skipped)
+	}
+	if len(res.Failed) != 1 || res.Failed["evt_10"] == nil {
+		t.Errorf("failed: %v", res.Failed)
+	}
+}
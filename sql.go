@@ -0,0 +1,38 @@
+package zstripe
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL dialect used by the database/sql-backed stores.
+type Dialect int
+
+// Supported SQL dialects.
+const (
+	SQLite Dialect = iota
+	PostgreSQL
+)
+
+// rebind replaces the ? placeholders in the query with $n for PostgreSQL.
+func (d Dialect) rebind(query string) string {
+	if d != PostgreSQL {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+	b.Grow(len(query) + 8)
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
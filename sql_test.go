@@ -0,0 +1,221 @@
+package zstripe
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The tests for the SQL stores use a small database/sql driver which runs
+// queries with the sqlite3 command-line tool, so we don't need to depend on a
+// SQLite driver; the tests are skipped if sqlite3 isn't in PATH.
+func init() {
+	sql.Register("zstripe-sqlite3", sqliteDriver{})
+}
+
+// testDB creates a new SQLite database with the schema.
+func testDB(t *testing.T, schema string) *sql.DB {
+	t.Helper()
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not in PATH")
+	}
+
+	db, err := sql.Open("zstripe-sqlite3", filepath.Join(t.TempDir(), "test.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+type (
+	sqliteDriver struct{}
+	sqliteConn   struct{ path string }
+	sqliteStmt   struct {
+		conn  *sqliteConn
+		query string
+	}
+	sqliteRows struct {
+		cols []string
+		rows [][]driver.Value
+	}
+	sqliteResult int64
+)
+
+func (sqliteDriver) Open(path string) (driver.Conn, error) { return &sqliteConn{path: path}, nil }
+
+func (c *sqliteConn) Prepare(q string) (driver.Stmt, error) {
+	return &sqliteStmt{conn: c, query: q}, nil
+}
+func (c *sqliteConn) Close() error              { return nil }
+func (c *sqliteConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (s *sqliteStmt) Close() error  { return nil }
+func (s *sqliteStmt) NumInput() int { return -1 }
+
+func (s *sqliteStmt) Exec(args []driver.Value) (driver.Result, error) {
+	q, err := bind(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.conn.run(q + ";\nselect changes() as n;")
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the last result set is the changes().
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var n []struct{ N int64 }
+	err = json.Unmarshal([]byte(lines[len(lines)-1]), &n)
+	if err != nil || len(n) != 1 {
+		return nil, fmt.Errorf("parsing changes(): %q: %v", out, err)
+	}
+	return sqliteResult(n[0].N), nil
+}
+
+func (s *sqliteStmt) Query(args []driver.Value) (driver.Rows, error) {
+	q, err := bind(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.conn.run(q)
+	if err != nil {
+		return nil, err
+	}
+	return parseRows(out)
+}
+
+func (c *sqliteConn) run(q string) ([]byte, error) {
+	cmd := exec.Command("sqlite3", "-bail", "-json", c.path)
+	cmd.Stdin = strings.NewReader(q)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3: %w: %s\nquery: %s", err, stderr, q)
+	}
+	return out, nil
+}
+
+// bind replaces the ? placeholders with literal values.
+func bind(q string, args []driver.Value) (string, error) {
+	var b strings.Builder
+	for _, c := range q {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+		if len(args) == 0 {
+			return "", fmt.Errorf("not enough arguments for %q", q)
+		}
+		switch a := args[0].(type) {
+		case nil:
+			b.WriteString("null")
+		case int64:
+			b.WriteString(strconv.FormatInt(a, 10))
+		case float64:
+			b.WriteString(strconv.FormatFloat(a, 'g', -1, 64))
+		case bool:
+			b.WriteString(map[bool]string{true: "1", false: "0"}[a])
+		case string:
+			b.WriteString("'" + strings.ReplaceAll(a, "'", "''") + "'")
+		case []byte:
+			b.WriteString("X'" + hex.EncodeToString(a) + "'")
+		default:
+			return "", fmt.Errorf("unsupported type %T", a)
+		}
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		return "", fmt.Errorf("too many arguments for %q", q)
+	}
+	return b.String(), nil
+}
+
+// parseRows parses the JSON output, keeping the column order.
+func parseRows(out []byte) (*sqliteRows, error) {
+	rows := &sqliteRows{}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return rows, nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(out))
+	d.UseNumber()
+	if _, err := d.Token(); err != nil { // [
+		return nil, err
+	}
+	for d.More() {
+		if _, err := d.Token(); err != nil { // {
+			return nil, err
+		}
+		var (
+			row  []driver.Value
+			cols []string
+		)
+		for d.More() {
+			k, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			var v interface{}
+			err = d.Decode(&v)
+			if err != nil {
+				return nil, err
+			}
+			if n, ok := v.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					v = i
+				} else {
+					v, _ = n.Float64()
+				}
+			}
+			cols, row = append(cols, k.(string)), append(row, v)
+		}
+		if _, err := d.Token(); err != nil { // }
+			return nil, err
+		}
+		rows.cols, rows.rows = cols, append(rows.rows, row)
+	}
+	return rows, nil
+}
+
+func (r *sqliteRows) Columns() []string { return r.cols }
+func (r *sqliteRows) Close() error      { return nil }
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func (r sqliteResult) LastInsertId() (int64, error) { return 0, errors.New("not supported") }
+func (r sqliteResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestRebind(t *testing.T) {
+	q := `select * from x where a=? and b=?`
+	if got := SQLite.rebind(q); got != q {
+		t.Errorf("sqlite: %q", got)
+	}
+	if got, want := PostgreSQL.rebind(q), `select * from x where a=$1 and b=$2`; got != want {
+		t.Errorf("postgres: %q", got)
+	}
+}
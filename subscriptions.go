@@ -0,0 +1,266 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Subscription statuses.
+const (
+	SubscriptionIncomplete = "incomplete"
+	SubscriptionTrialing   = "trialing"
+	SubscriptionActive     = "active"
+	SubscriptionPastDue    = "past_due"
+	SubscriptionUnpaid     = "unpaid"
+	SubscriptionPaused     = "paused"
+	SubscriptionCanceled   = "canceled"
+)
+
+// SubscriptionState is the subscription state of a customer.
+type SubscriptionState struct {
+	Customer     string
+	Subscription string
+	Status       string // One of the Subscription* constants; empty if there's no subscription yet.
+
+	TrialEnd          time.Time // Zero if there's no trial.
+	CurrentPeriodEnd  time.Time
+	CancelAtPeriodEnd bool
+
+	// Event that last changed the state, and when it was created.
+	EventID string
+	Updated time.Time
+}
+
+// Entitled reports if the customer should have access: the subscription is
+// trialing, active, or past due (i.e. Stripe is still retrying the payment).
+func (s SubscriptionState) Entitled() bool {
+	return s.Status == SubscriptionTrialing || s.Status == SubscriptionActive || s.Status == SubscriptionPastDue
+}
+
+// SubscriptionStore stores the subscription state for customers.
+type SubscriptionStore interface {
+	// Get the state for a customer; this should return the zero value with a
+	// nil error if there's no state for the customer yet.
+	Get(customer string) (SubscriptionState, error)
+
+	// Put stores the state for a customer.
+	Put(SubscriptionState) error
+}
+
+// MemorySubscriptionStore stores subscription states in memory.
+type MemorySubscriptionStore struct {
+	mu sync.Mutex
+	m  map[string]SubscriptionState
+}
+
+func (m *MemorySubscriptionStore) Get(customer string) (SubscriptionState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.m[customer], nil
+}
+
+func (m *MemorySubscriptionStore) Put(s SubscriptionState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[string]SubscriptionState)
+	}
+	m.m[s.Customer] = s
+	return nil
+}
+
+// SubscriptionTracker keeps track of the subscription state for every customer
+// from webhook events.
+//
+// This handles the customer.subscription.* events, invoice.paid, and
+// invoice.payment_failed. Every customer is assumed to have at most one
+// current subscription; events for an older subscription don't override the
+// state of a newer one.
+//
+// Events may be delivered out of order: events older than the stored state are
+// ignored, and a canceled subscription is never made active again. Stripe's
+// timestamps are in seconds, so for events created in the same second as the
+// stored state the status never moves back (e.g. from active to incomplete),
+// and customer.subscription.created is ignored.
+//
+// Concurrent calls to Handle are serialized; if you share the Store between
+// processes you'll need to make sure events for the same customer aren't
+// handled at the same time (e.g. with Queue).
+type SubscriptionTracker struct {
+	Store SubscriptionStore
+
+	// Called when the status changes, before the new state is stored; if this
+	// returns an error the state isn't stored and Handle returns the error, so
+	// the event can be retried.
+	Transition func(from, to SubscriptionState) error
+
+	// Called for customer.subscription.trial_will_end events, which are sent
+	// three days before the trial ends.
+	TrialWillEnd func(SubscriptionState) error
+
+	mu sync.Mutex
+}
+
+// Handle the event; other events are ignored. This can be used as an
+// EventHandler.
+func (t *SubscriptionTracker) Handle(e Event) error {
+	var (
+		next SubscriptionState
+		err  error
+	)
+	switch {
+	case strings.HasPrefix(e.Type, "customer.subscription."):
+		next, err = subscriptionState(e)
+	case e.Type == EventInvoicePaid || e.Type == EventInvoicePaymentFailed:
+		next, err = invoiceState(e)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("zstripe.SubscriptionTracker: %s: %w", e.ID, err)
+	}
+	if next.Customer == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, err := t.Store.Get(next.Customer)
+	if err != nil {
+		return fmt.Errorf("zstripe.SubscriptionTracker: %w", err)
+	}
+
+	if e.Type == EventCustomerSubscriptionTrialWillEnd {
+		if t.TrialWillEnd != nil && (prev.Subscription == "" || prev.Subscription == next.Subscription) {
+			return t.TrialWillEnd(next)
+		}
+		return nil
+	}
+
+	next, ok := t.merge(e, prev, next)
+	if !ok {
+		return nil
+	}
+	if t.Transition != nil && next.Status != prev.Status {
+		err := t.Transition(prev, next)
+		if err != nil {
+			return err
+		}
+	}
+	err = t.Store.Put(next)
+	if err != nil {
+		return fmt.Errorf("zstripe.SubscriptionTracker: %w", err)
+	}
+	return nil
+}
+
+// merge the state from the event with the stored state; this returns false if
+// the event should be ignored.
+func (t *SubscriptionTracker) merge(e Event, prev, next SubscriptionState) (SubscriptionState, bool) {
+	if next.Updated.Before(prev.Updated) {
+		return prev, false
+	}
+
+	// Invoices only change the status of the current subscription.
+	if strings.HasPrefix(e.Type, "invoice.") {
+		if next.Subscription != prev.Subscription {
+			return prev, false
+		}
+		s := prev
+		switch {
+		case e.Type == EventInvoicePaid && (s.Status == SubscriptionPastDue || s.Status == SubscriptionUnpaid ||
+			s.Status == SubscriptionIncomplete):
+			s.Status = SubscriptionActive
+		case e.Type == EventInvoicePaymentFailed && s.Status == SubscriptionActive:
+			s.Status = SubscriptionPastDue
+		default:
+			return prev, false
+		}
+		s.EventID, s.Updated = next.EventID, next.Updated
+		return s, true
+	}
+
+	if prev.Subscription != "" && prev.Subscription == next.Subscription &&
+		prev.Status == SubscriptionCanceled && next.Status != SubscriptionCanceled {
+		return prev, false
+	}
+	if prev.Subscription == next.Subscription && next.Updated.Equal(prev.Updated) &&
+		(e.Type == EventCustomerSubscriptionCreated || statusOrder(next.Status) < statusOrder(prev.Status)) {
+		return prev, false
+	}
+	if prev.Subscription != "" && prev.Subscription != next.Subscription &&
+		prev.Status != SubscriptionCanceled && next.Status == SubscriptionCanceled {
+		return prev, false
+	}
+	return next, true
+}
+
+// statusOrder gets the order of the status in the subscription's lifecycle.
+func statusOrder(status string) int {
+	switch status {
+	case SubscriptionIncomplete:
+		return 0
+	case SubscriptionTrialing:
+		return 1
+	case SubscriptionCanceled:
+		return 3
+	default:
+		return 2
+	}
+}
+
+func subscriptionState(e Event) (SubscriptionState, error) {
+	var sub struct {
+		ID                string          `json:"id"`
+		Customer          json.RawMessage `json:"customer"`
+		Status            string          `json:"status"`
+		TrialEnd          int64           `json:"trial_end"`
+		CurrentPeriodEnd  int64           `json:"current_period_end"`
+		CancelAtPeriodEnd bool            `json:"cancel_at_period_end"`
+	}
+	err := json.Unmarshal(e.Data.Raw, &sub)
+	if err != nil {
+		return SubscriptionState{}, err
+	}
+
+	s := SubscriptionState{
+		Customer:          expandableID(sub.Customer),
+		Subscription:      sub.ID,
+		Status:            sub.Status,
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		EventID:           e.ID,
+		Updated:           time.Unix(e.Created, 0).UTC(),
+	}
+	if sub.TrialEnd > 0 {
+		s.TrialEnd = time.Unix(sub.TrialEnd, 0).UTC()
+	}
+	if sub.CurrentPeriodEnd > 0 {
+		s.CurrentPeriodEnd = time.Unix(sub.CurrentPeriodEnd, 0).UTC()
+	}
+	return s, nil
+}
+
+func invoiceState(e Event) (SubscriptionState, error) {
+	var inv struct {
+		Customer     json.RawMessage `json:"customer"`
+		Subscription json.RawMessage `json:"subscription"`
+	}
+	err := json.Unmarshal(e.Data.Raw, &inv)
+	if err != nil {
+		return SubscriptionState{}, err
+	}
+	sub := expandableID(inv.Subscription)
+	if sub == "" { // Not a subscription invoice.
+		return SubscriptionState{}, nil
+	}
+	return SubscriptionState{
+		Customer:     expandableID(inv.Customer),
+		Subscription: sub,
+		EventID:      e.ID,
+		Updated:      time.Unix(e.Created, 0).UTC(),
+	}, nil
+}
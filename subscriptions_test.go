@@ -0,0 +1,149 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func subEvent(id string, created int64, typ, sub, status string) Event {
+	e := Event{ID: id, Type: typ, Created: created}
+	if strings.HasPrefix(typ, "invoice.") {
+		e.Data.Raw = json.RawMessage(fmt.Sprintf(`{"id": "in_%s", "customer": "cus_1", "subscription": %q}`, id, sub))
+	} else {
+		e.Data.Raw = json.RawMessage(fmt.Sprintf(
+			`{"id": %q, "customer": {"id": "cus_1"}, "status": %q, "trial_end": null, "current_period_end": 1700000000}`,
+			sub, status))
+	}
+	return e
+}
+
+func TestSubscriptionTracker(t *testing.T) {
+	const (
+		created  = EventCustomerSubscriptionCreated
+		updated  = EventCustomerSubscriptionUpdated
+		deleted  = EventCustomerSubscriptionDeleted
+		paid     = EventInvoicePaid
+		failed   = EventInvoicePaymentFailed
+		trialEnd = EventCustomerSubscriptionTrialWillEnd
+	)
+
+	tests := []struct {
+		name   string
+		events []Event
+		want   string
+	}{
+		{"in order", []Event{
+			subEvent("1", 1, created, "sub_1", "trialing"),
+			subEvent("2", 2, trialEnd, "sub_1", "trialing"),
+			subEvent("3", 3, failed, "sub_1", ""),
+			subEvent("4", 4, updated, "sub_1", "active"),
+			subEvent("5", 5, failed, "sub_1", ""),
+			subEvent("6", 6, paid, "sub_1", ""),
+			subEvent("7", 7, deleted, "sub_1", "canceled"),
+		}, "→trialing trial_will_end trialing→active active→past_due past_due→active active→canceled = sub_1 canceled 7"},
+
+		{"updated before created", []Event{
+			subEvent("2", 2, updated, "sub_1", "active"),
+			subEvent("1", 1, created, "sub_1", "trialing"),
+		}, "→active = sub_1 active 2"},
+
+		{"deleted before updated in same second", []Event{
+			subEvent("2", 2, deleted, "sub_1", "canceled"),
+			subEvent("1", 2, updated, "sub_1", "active"),
+		}, "→canceled = sub_1 canceled 2"},
+
+		{"created after updated in same second", []Event{
+			subEvent("2", 2, updated, "sub_1", "active"),
+			subEvent("1", 2, created, "sub_1", "incomplete"),
+		}, "→active = sub_1 active 2"},
+
+		{"trialing after active in same second", []Event{
+			subEvent("2", 2, updated, "sub_1", "active"),
+			subEvent("1", 2, updated, "sub_1", "trialing"),
+		}, "→active = sub_1 active 2"},
+
+		{"past_due after active in same second", []Event{
+			subEvent("1", 2, updated, "sub_1", "active"),
+			subEvent("2", 2, updated, "sub_1", "past_due"),
+		}, "→active active→past_due = sub_1 past_due 2"},
+
+		{"old invoice", []Event{
+			subEvent("2", 2, updated, "sub_1", "active"),
+			subEvent("1", 1, failed, "sub_1", ""),
+		}, "→active = sub_1 active 2"},
+
+		{"new subscription", []Event{
+			subEvent("1", 1, created, "sub_1", "active"),
+			subEvent("3", 3, created, "sub_2", "active"),
+			subEvent("2", 3, deleted, "sub_1", "canceled"),
+			subEvent("4", 4, failed, "sub_1", ""),
+		}, "→active = sub_2 active 3"},
+
+		{"resubscribe", []Event{
+			subEvent("1", 1, deleted, "sub_1", "canceled"),
+			subEvent("2", 2, created, "sub_2", "active"),
+			subEvent("3", 3, "customer.subscription.paused", "sub_2", "paused"),
+		}, "→canceled canceled→active active→paused = sub_2 paused 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			tr := SubscriptionTracker{
+				Store: &MemorySubscriptionStore{},
+				Transition: func(from, to SubscriptionState) error {
+					log = append(log, from.Status+"→"+to.Status)
+					return nil
+				},
+				TrialWillEnd: func(SubscriptionState) error {
+					log = append(log, "trial_will_end")
+					return nil
+				},
+			}
+			for _, e := range tt.events {
+				err := tr.Handle(e)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s, _ := tr.Store.Get("cus_1")
+			got := fmt.Sprintf("%s = %s %s %s", strings.Join(log, " "), s.Subscription, s.Status, s.EventID)
+			if got != tt.want {
+				t.Errorf("\ngot:  %s\nwant: %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionTrackerError(t *testing.T) {
+	fail := true
+	tr := SubscriptionTracker{
+		Store: &MemorySubscriptionStore{},
+		Transition: func(from, to SubscriptionState) error {
+			if fail {
+				return errors.New("oh noes")
+			}
+			return nil
+		},
+	}
+
+	e := subEvent("1", 1, EventCustomerSubscriptionCreated, "sub_1", "active")
+	if err := tr.Handle(e); err == nil {
+		t.Fatal("no error")
+	}
+	if s, _ := tr.Store.Get("cus_1"); s.Status != "" {
+		t.Fatalf("stored: %v", s)
+	}
+
+	fail = false
+	if err := tr.Handle(e); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := tr.Store.Get("cus_1"); !s.Entitled() || s.CurrentPeriodEnd.Unix() != 1700000000 || !s.TrialEnd.IsZero() {
+		t.Fatalf("stored: %v", s)
+	}
+}
@@ -0,0 +1,93 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestV2(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/core/event_destinations":
+			if r.Method == "POST" {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type: %q", ct)
+				}
+				fmt.Fprintln(w, `{"id": "ed_1"}`)
+				return
+			}
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprintln(w, `{"data": [{"id": "ed_3"}], "next_page_url": null}`)
+				return
+			}
+			fmt.Fprintln(w, `{"data": [{"id": "ed_1"}, {"id": "ed_2"}], "next_page_url": "/v2/core/event_destinations?page=2"}`)
+		case "/v1/billing/meters/mtr_1":
+			if a := r.Header.Get("Stripe-Account"); a != "acct_1" {
+				t.Errorf("Stripe-Account: %q", a)
+			}
+			fmt.Fprintln(w, `{"id": "mtr_1", "status": "active"}`)
+		case "/v1/customers":
+			if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+				t.Errorf("Content-Type: %q", ct)
+			}
+			fmt.Fprintln(w, `{"id": "cus_1"}`)
+		}
+	}))
+	defer api.Close()
+	API, SecretKey = api.URL, "sk_test_xxx"
+
+	for _, p := range []string{"/v2/core/event_destinations", "/v1/customers"} {
+		_, err := Request(nil, "POST", p, `{}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ids []string
+	err := ListV2("/v2/core/event_destinations", nil, func(o json.RawMessage) error {
+		var id ID
+		err := json.Unmarshal(o, &id)
+		ids = append(ids, id.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ed_1", "ed_2", "ed_3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ListV2: %v", ids)
+	}
+
+	SignSecret = "whsec_test"
+	defer func() { SignSecret = "" }()
+	body := `{"id": "evt_1", "object": "v2.core.event", "type": "v1.billing.meter.error_report_triggered",
+		"created": "2024-09-26T12:00:00.000Z", "context": "acct_1",
+		"related_object": {"id": "mtr_1", "type": "billing.meter", "url": "/v1/billing/meters/mtr_1"}}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Stripe-Signature", Sign([]byte(body), time.Now()))
+
+	var e ThinEvent
+	err = e.Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Created.Year() != 2024 || e.RelatedObject.ID != "mtr_1" {
+		t.Errorf("%#v", e)
+	}
+
+	var meter struct {
+		Status string `json:"status"`
+	}
+	err = e.FetchRelated(&meter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meter.Status != "active" {
+		t.Errorf("status: %q", meter.Status)
+	}
+}
@@ -0,0 +1,141 @@
+package zstripe
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Verifier verifies webhook signatures.
+//
+// The zero value uses SignSecret, MaxAge, MaxFuture, WebhookMode, and
+// WebhookAccount.
+type Verifier struct {
+	Secret    string           // Signing secret (whsec_*); defaults to SignSecret.
+	Now       func() time.Time // Current time; defaults to time.Now.
+	MaxAge    time.Duration    // Reject signatures older than this; defaults to MaxAge.
+	MaxFuture time.Duration    // Reject signatures further in the future than this; defaults to MaxFuture.
+	Mode      Mode             // Reject events not for this mode; defaults to WebhookMode.
+	Account   string           // Reject events for other accounts; defaults to WebhookAccount.
+}
+
+func (v Verifier) defaults() Verifier {
+	if v.Secret == "" {
+		v.Secret = SignSecret
+	}
+	if v.Now == nil {
+		v.Now = time.Now
+	}
+	if v.MaxAge == 0 {
+		v.MaxAge = MaxAge
+	}
+	if v.MaxFuture == 0 {
+		v.MaxFuture = MaxFuture
+	}
+	if v.Mode == ModeAny {
+		v.Mode = WebhookMode
+	}
+	if v.Account == "" {
+		v.Account = WebhookAccount
+	}
+	return v
+}
+
+// Check the livemode and account of an event.
+func (v Verifier) Check(livemode bool, account string) error {
+	v = v.defaults()
+	if err := v.checkMode(livemode); err != nil {
+		return err
+	}
+	if v.Account != "" && account != v.Account {
+		return fmt.Errorf("%w: %q", ErrWebhookAccount, account)
+	}
+	return nil
+}
+
+func (v Verifier) checkMode(livemode bool) error {
+	v = v.defaults()
+	if !v.Mode.allows(livemode) {
+		return fmt.Errorf("%w: livemode=%t; mode is %s", ErrWebhookLivemode, livemode, v.Mode)
+	}
+	return nil
+}
+
+// Verify the Stripe-Signature header for the payload.
+//
+// The timestamp from the header is returned, which can be compared to your
+// clock to measure the drift. It's returned even if the signature is invalid,
+// but only if the header could be parsed.
+func (v Verifier) Verify(header string, payload []byte) (time.Time, error) {
+	v = v.defaults()
+	if v.Secret == "" {
+		panic("zstripe.Verifier: must set zstripe.SignSecret or Verifier.Secret")
+	}
+	if v.Secret == "testing" {
+		return time.Time{}, nil
+	}
+
+	h, err := ParseSignatureHeader(header)
+	if err != nil {
+		return h.Timestamp, err
+	}
+	ts, now := h.Timestamp, v.Now()
+	if now.Sub(ts) > v.MaxAge {
+		return ts, ErrWebhookTooOld
+	}
+	if ts.Sub(now) > v.MaxFuture {
+		return ts, ErrWebhookInFuture
+	}
+
+	sig := signature(v.Secret, ts, payload)
+	for _, s := range h.Signatures["v1"] {
+		if hmac.Equal(sig, s) {
+			return ts, nil
+		}
+	}
+	return ts, ErrWebhookInvalidSignature
+}
+
+// Read the request body and verify the signature.
+//
+// This returns the request body and the timestamp from the signature.
+func (v Verifier) Read(r *http.Request) ([]byte, time.Time, error) {
+	b, err := readBody(r)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	ts, err := v.Verify(r.Header.Get("Stripe-Signature"), b)
+	if err != nil {
+		return nil, ts, err
+	}
+	return b, ts, nil
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("zstripe.Event.Read: %w", err)
+	}
+	if int64(len(b)) > MaxBodySize {
+		return nil, ErrWebhookTooLarge
+	}
+	return b, nil
+}
+
+// ReadEvent reads the event from the request body and verifies the signature
+// and the livemode and account; this is like Event.ReadRaw, but also returns
+// the timestamp from the signature.
+func (v Verifier) ReadEvent(r *http.Request, e *Event) ([]byte, time.Time, error) {
+	b, ts, err := v.Read(r)
+	if err != nil {
+		return nil, ts, err
+	}
+	err = e.decode(b)
+	if err != nil {
+		return nil, ts, err
+	}
+	return b, ts, v.Check(e.Livemode, e.Account)
+}
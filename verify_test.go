@@ -0,0 +1,102 @@
+package zstripe
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifier(t *testing.T) {
+	var (
+		now     = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+		payload = []byte(`{"id": "evt_1"}`)
+		v       = Verifier{
+			Secret:    "whsec_test",
+			Now:       func() time.Time { return now },
+			MaxAge:    time.Minute,
+			MaxFuture: 10 * time.Second,
+		}
+	)
+	SignSecret = "whsec_test"
+	defer func() { SignSecret = "" }()
+
+	tests := []struct {
+		signed  time.Time
+		header  string
+		wantErr error
+	}{
+		{now, "", nil},
+		{now.Add(-59 * time.Second), "", nil},
+		{now.Add(-61 * time.Second), "", ErrWebhookTooOld},
+		{now.Add(9 * time.Second), "", nil},
+		{now.Add(11 * time.Second), "", ErrWebhookInFuture},
+		{now, "t=1622548800,v1=0000", ErrWebhookInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			h := tt.header
+			if h == "" {
+				h = Sign(payload, tt.signed)
+			}
+			ts, err := v.Verify(h, payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("wrong error\ngot:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if !ts.Equal(tt.signed) {
+				t.Errorf("timestamp: %s", ts)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(string(payload)))
+	r.Header.Set("Stripe-Signature", Sign(payload, now.Add(-5*time.Second)))
+	var e Event
+	_, ts, err := v.ReadEvent(r, &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drift := now.Sub(ts); drift != 5*time.Second || e.ID != "evt_1" {
+		t.Errorf("drift=%s; e.ID=%q", drift, e.ID)
+	}
+}
+
+func TestVerifierCheck(t *testing.T) {
+	defer func() { WebhookMode, WebhookAccount = ModeAny, "" }()
+
+	tests := []struct {
+		v        Verifier
+		livemode bool
+		account  string
+		wantErr  error
+	}{
+		{Verifier{}, true, "", nil},
+		{Verifier{}, false, "acct_1", nil},
+		{Verifier{Mode: ModeLive}, true, "", nil},
+		{Verifier{Mode: ModeLive}, false, "", ErrWebhookLivemode},
+		{Verifier{Mode: ModeTest}, true, "", ErrWebhookLivemode},
+		{Verifier{Account: "acct_1"}, true, "acct_1", nil},
+		{Verifier{Account: "acct_1"}, true, "acct_2", ErrWebhookAccount},
+		{Verifier{Account: "acct_1"}, true, "", ErrWebhookAccount},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := tt.v.Check(tt.livemode, tt.account)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("wrong error\ngot:  %v\nwant: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Globals.
+	WebhookMode = ModeLive
+	SignSecret = "testing"
+	defer func() { SignSecret = "" }()
+	var e Event
+	err := e.Read(httptest.NewRequest("POST", "/", strings.NewReader(`{"id": "evt_1", "livemode": false}`)))
+	if !errors.Is(err, ErrWebhookLivemode) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,28 +17,61 @@ import (
 // Possible errors when validating a webhook.
 var (
 	ErrWebhookTooOld           = errors.New("zstripe.Event.Read: webhook too old")
+	ErrWebhookInFuture         = errors.New("zstripe.Event.Read: webhook timestamp in the future")
 	ErrWebhookInvalidHeader    = errors.New("zstripe.Event.Read: invalid Stripe-Signature header")
 	ErrWebhookInvalidSignature = errors.New("zstripe.Event.Read: invalid signature")
+	ErrWebhookMissingHeader    = errors.New("zstripe.Event.Read: no Stripe-Signature header")
+	ErrWebhookMissingTimestamp = errors.New("zstripe.Event.Read: no timestamp in Stripe-Signature header")
+	ErrWebhookNoSignature      = errors.New("zstripe.Event.Read: no v1 signature in Stripe-Signature header")
+	ErrWebhookVersion          = errors.New("zstripe.Event.Read: api_version doesn't match StripeVersion")
+	ErrWebhookTooLarge         = errors.New("zstripe.Event.Read: request body larger than MaxBodySize")
+	ErrWebhookLivemode         = errors.New("zstripe.Event.Read: livemode doesn't match WebhookMode")
+	ErrWebhookAccount          = errors.New("zstripe.Event.Read: account doesn't match WebhookAccount")
 )
 
 var (
 	// Stripe signing secret (whsec_*). This can be set to "testing" to skip,
 	// which is not recommended outside of tests since anyone can send anything
 	// to your webhook.
-	//
-	// TODO: add a Sign() function to sign webhooks.
 	SignSecret string
 
 	// Reject signatures older than this, to prevent replay attacks.
 	MaxAge = 300 * time.Second
+
+	// Reject signatures further in the future than this, which usually means
+	// your clock is wrong.
+	MaxFuture = 300 * time.Second
+
+	// Reject request bodies larger than this many bytes with
+	// ErrWebhookTooLarge.
+	MaxBodySize int64 = 1 << 20
+
+	// Reject events that are not for this mode with ErrWebhookLivemode; for
+	// example set this to ModeLive in production to make sure test events are
+	// never processed.
+	WebhookMode = ModeAny
+
+	// Reject events for another Connect account with ErrWebhookAccount. Events
+	// for your own account have no account set, so they're rejected too.
+	WebhookAccount = ""
+
+	// Reject events with an api_version that's different from StripeVersion
+	// with ErrWebhookVersion. Also see Event.VersionMismatch().
+	RejectVersion = false
+
+	// Called from Event.Read for events with a type that's not in EventTypes;
+	// this can be used to log or report them.
+	UnknownEvent func(Event)
 )
 
 // https://stripe.com/docs/api#events.
 type Event struct {
 	ID              string `json:"id"`
+	Object          string `json:"object"` // Always "event".
 	Type            string `json:"type"`
 	Livemode        bool   `json:"livemode"`
 	Created         int64  `json:"created"`
+	APIVersion      string `json:"api_version"`      // API version used to render the data; may be empty for old events.
 	Account         string `json:"account"`          // Account that originated the event (Connect only).
 	PendingWebhooks int64  `json:"pending_webhooks"` // Number of webhooks that still need to be delivered.
 
@@ -56,65 +89,110 @@ type Event struct {
 
 	// Details about the request that created the event; may be empty as not all
 	// events are created by a request.
-	Request struct {
-		ID             string `json:"id"`
-		IdempotencyKey string `json:"idempotency_key"`
-	} `json:"request"`
+	Request EventRequest `json:"request"`
 }
 
-// Read the event from the request body and validate the signature.
-func (e *Event) Read(r *http.Request) error {
-	if SignSecret == "" {
-		panic("zstripe.Event.Read: must set zstripe.SignSecret")
+// EventRequest is the request that created an event.
+type EventRequest struct {
+	ID             string `json:"id"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// UnmarshalJSON also accepts the request ID as a string, which is what API
+// versions before 2017-05-25 use.
+func (r *EventRequest) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		*r = EventRequest{}
+		return json.Unmarshal(b, &r.ID)
 	}
 
-	b, err := io.ReadAll(r.Body)
+	type alias EventRequest
+	var a alias
+	err := json.Unmarshal(b, &a)
 	if err != nil {
-		return fmt.Errorf("zstripe.Event.Read: %w", err)
+		return err
 	}
+	*r = EventRequest(a)
+	return nil
+}
 
-	if SignSecret != "testing" {
-		ts, sigs, err := parseHeader(r.Header.Get("Stripe-Signature"))
-		if err != nil {
-			return fmt.Errorf("zstripe.Event.Read: %w", err)
-		}
-		if time.Since(ts) > MaxAge {
-			return ErrWebhookTooOld
-		}
+// VersionMismatch reports if the event's api_version is different from
+// StripeVersion, in which case the data may not be in the format you expect.
+//
+// This always returns false if either is empty.
+func (e Event) VersionMismatch() bool {
+	return StripeVersion != "" && e.APIVersion != "" && e.APIVersion != StripeVersion
+}
 
-		mac := hmac.New(sha256.New, []byte(SignSecret))
-		mac.Write([]byte(fmt.Sprintf("%d", ts.Unix())))
-		mac.Write([]byte("."))
-		mac.Write(b)
-		sig := mac.Sum(nil)
-		found := false
-		for _, s := range sigs {
-			if hmac.Equal(sig, s) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return ErrWebhookInvalidSignature
-		}
-	}
+// EventHandler processes a single event.
+type EventHandler func(Event) error
+
+// Read the event from the request body and validate the signature.
+func (e *Event) Read(r *http.Request) error {
+	_, err := e.ReadRaw(r)
+	return err
+}
 
-	err = json.Unmarshal(b, &e)
+// ReadRaw reads the event from the request body and validates the signature,
+// like Read, and also returns the request body.
+//
+// This is exactly what Stripe sent, which is useful if you want to store or
+// archive it.
+func (e *Event) ReadRaw(r *http.Request) ([]byte, error) {
+	b, _, err := Verifier{}.ReadEvent(r, e)
+	return b, err
+}
+
+func (e *Event) decode(b []byte) error {
+	err := json.Unmarshal(b, &e)
 	if err != nil {
 		return fmt.Errorf("zstripe.Event.Read: %w", err)
 	}
+	if RejectVersion && e.VersionMismatch() {
+		return fmt.Errorf("%w: %q", ErrWebhookVersion, e.APIVersion)
+	}
+	if UnknownEvent != nil && !KnownEvent(e.Type) {
+		UnknownEvent(*e)
+	}
 	return nil
 }
 
+// Sign the payload with SignSecret, returning the value for the
+// Stripe-Signature header.
+//
+// This is useful to re-deliver events or to test your webhook handler.
+func Sign(payload []byte, t time.Time) string {
+	if SignSecret == "" {
+		panic("zstripe.Sign: must set zstripe.SignSecret")
+	}
+	return fmt.Sprintf("t=%d,v1=%x", t.Unix(), signature(SignSecret, t, payload))
+}
+
+func signature(secret string, t time.Time, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d", t.Unix())))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// SignatureHeader is a parsed Stripe-Signature header.
+type SignatureHeader struct {
+	Timestamp  time.Time
+	Signatures map[string][][]byte // Signatures by scheme, e.g. "v1" or "v0".
+}
+
+// ParseSignatureHeader parses a Stripe-Signature header.
+//
 // The Stripe-Signature header contains a timestamp and one or more signatures.
 // The timestamp is prefixed by t=, and each signature is prefixed by a scheme.
 // Schemes start with v, followed by an integer. Currently, the only valid
 // signature scheme is v1. To aid with testing, Stripe sends an additional
 // signature with a fake v0 scheme, for test-mode events.
 //
-// Stripe-Signature: t=1492774577,
-//     v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd,
-//     v0=6ffbb59b2300aae63f272406069a9788598b792a944a07aba816edb039989a39
+//	Stripe-Signature: t=1492774577,
+//	    v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd,
+//	    v0=6ffbb59b2300aae63f272406069a9788598b792a944a07aba816edb039989a39
 //
 // Note that newlines have been added in the example above for clarity, but a
 // real Stripe-Signature header will be all one line.
@@ -128,44 +206,72 @@ func (e *Event) Read(r *http.Request) error {
 // choose to keep the previous secret active for up to 24 hours. During this
 // time, your endpoint has multiple active secrets and Stripe generates one
 // signature for each secret.
-func parseHeader(header string) (time.Time, [][]byte, error) {
-	var (
-		ts   time.Time
-		sigs [][]byte
-	)
-
+//
+// All schemes are returned, but signatures that aren't valid hex are ignored.
+// Verifier only uses the v1 signatures.
+func ParseSignatureHeader(header string) (SignatureHeader, error) {
+	h := SignatureHeader{Signatures: make(map[string][][]byte)}
 	if header == "" {
-		return ts, nil, ErrWebhookInvalidSignature
+		return h, ErrWebhookMissingHeader
 	}
 
 	for _, item := range strings.Split(header, ",") {
-		parts := strings.Split(item, "=")
-		if len(parts) != 2 {
-			return ts, nil, ErrWebhookInvalidSignature
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return h, fmt.Errorf("%w: %q", ErrWebhookInvalidHeader, item)
 		}
 
-		switch parts[0] {
-		case "t":
-			timestamp, err := strconv.ParseInt(parts[1], 10, 64)
-			if err != nil {
-				return ts, nil, ErrWebhookInvalidSignature
+		switch k, v := parts[0], parts[1]; {
+		case k == "t":
+			if !h.Timestamp.IsZero() {
+				return h, fmt.Errorf("%w: more than one timestamp", ErrWebhookInvalidHeader)
+			}
+			ts, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || ts <= 0 {
+				return h, fmt.Errorf("%w: invalid timestamp %q", ErrWebhookInvalidHeader, v)
 			}
-			ts = time.Unix(timestamp, 0)
+			h.Timestamp = time.Unix(ts, 0)
 
-		case "v1":
-			sig, err := hex.DecodeString(parts[1])
-			if err != nil {
+		case len(k) > 1 && k[0] == 'v':
+			sig, err := hex.DecodeString(v)
+			if err != nil || len(sig) == 0 {
 				continue // Ignore invalid signatures.
 			}
-			sigs = append(sigs, sig)
+			h.Signatures[k] = append(h.Signatures[k], sig)
 
 		default:
 			continue // Ignore unknown parts of the header.
 		}
 	}
 
-	if len(sigs) == 0 {
-		return ts, nil, ErrWebhookInvalidSignature
+	if h.Timestamp.IsZero() {
+		return h, ErrWebhookMissingTimestamp
+	}
+	if len(h.Signatures["v1"]) == 0 {
+		return h, ErrWebhookNoSignature
+	}
+	return h, nil
+}
+
+// String formats the header as a Stripe-Signature header; schemes are sorted
+// alphabetically.
+func (h SignatureHeader) String() string {
+	schemes := make([]string, 0, len(h.Signatures))
+	for s := range h.Signatures {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+
+	var b strings.Builder
+	b.WriteString("t=")
+	b.WriteString(strconv.FormatInt(h.Timestamp.Unix(), 10))
+	for _, s := range schemes {
+		for _, sig := range h.Signatures[s] {
+			b.WriteByte(',')
+			b.WriteString(s)
+			b.WriteByte('=')
+			b.WriteString(hex.EncodeToString(sig))
+		}
 	}
-	return ts, sigs, nil
+	return b.String()
 }
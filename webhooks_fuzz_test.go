@@ -0,0 +1,57 @@
+//go:build go1.18
+// +build go1.18
+
+package zstripe
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func FuzzParseSignatureHeader(f *testing.F) {
+	f.Add("t=1492774577,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd,v0=6ffbb59b2300aae63f272406069a9788598b792a944a07aba816edb039989a39")
+	f.Add("t=123,v1=abcd,v1=ef")
+	f.Add("t=1,v1==")
+	f.Fuzz(func(t *testing.T, header string) {
+		h, err := ParseSignatureHeader(header)
+		if err != nil {
+			return
+		}
+		h2, err := ParseSignatureHeader(h.String())
+		if err != nil {
+			t.Fatalf("parsing %q: %s", h.String(), err)
+		}
+		if !h.Timestamp.Equal(h2.Timestamp) || !reflect.DeepEqual(h.Signatures, h2.Signatures) {
+			t.Fatalf("round-trip failed\nin:  %#v\nout: %#v", h, h2)
+		}
+	})
+}
+
+func FuzzSignatureRoundTrip(f *testing.F) {
+	f.Add("whsec_test", []byte(`{"id": "evt_1"}`), int64(1622548800))
+	f.Fuzz(func(t *testing.T, secret string, payload []byte, ts int64) {
+		if secret == "" || secret == "testing" || ts <= 0 || ts > 1<<40 {
+			return
+		}
+		signed := time.Unix(ts, 0)
+		h := SignatureHeader{
+			Timestamp:  signed,
+			Signatures: map[string][][]byte{"v1": {signature(secret, signed, payload)}},
+		}
+
+		v := Verifier{Secret: secret, Now: func() time.Time { return signed }}
+		got, err := v.Verify(h.String(), payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(signed) {
+			t.Fatalf("timestamp: %s", got)
+		}
+
+		_, err = v.Verify(h.String(), append(payload, 'x'))
+		if err == nil {
+			t.Fatal("modified payload verified")
+		}
+	})
+}
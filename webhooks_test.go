@@ -0,0 +1,113 @@
+package zstripe
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventRequest(t *testing.T) {
+	tests := []struct {
+		in   string
+		want EventRequest
+	}{
+		{`{}`, EventRequest{}},
+		{`{"request": null}`, EventRequest{}},
+		{`{"request": "req_1"}`, EventRequest{ID: "req_1"}},
+		{`{"request": {"id": "req_1", "idempotency_key": "k"}}`, EventRequest{ID: "req_1", IdempotencyKey: "k"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var e Event
+			err := json.Unmarshal([]byte(tt.in), &e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Request != tt.want {
+				t.Errorf("\ngot:  %#v\nwant: %#v", e.Request, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventVersion(t *testing.T) {
+	SignSecret, StripeVersion, RejectVersion = "whsec_test", "2020-08-27", true
+	defer func() { SignSecret, StripeVersion, RejectVersion = "", "", false }()
+
+	for _, v := range []string{"", "2020-08-27", "2019-01-01"} {
+		body := `{"id": "evt_1", "object": "event", "api_version": "` + v + `"}`
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Stripe-Signature", Sign([]byte(body), time.Now()))
+
+		var e Event
+		err := e.Read(r)
+		if mismatch := v == "2019-01-01"; mismatch != errors.Is(err, ErrWebhookVersion) || mismatch != e.VersionMismatch() {
+			t.Errorf("%q: err=%v; VersionMismatch=%t", v, err, e.VersionMismatch())
+		}
+		if e.APIVersion != v || e.Object != "event" {
+			t.Errorf("%q: %#v", v, e)
+		}
+	}
+}
+
+func TestEventReadRaw(t *testing.T) {
+	SignSecret = "whsec_test"
+	defer func() { SignSecret, MaxBodySize = "", 1<<20 }()
+
+	body := `{"id":  "evt_1",   "type": "invoice.paid"}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Stripe-Signature", Sign([]byte(body), time.Now()))
+
+	var e Event
+	raw, err := e.ReadRaw(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != body || e.ID != "evt_1" {
+		t.Errorf("raw=%q; e=%#v", raw, e)
+	}
+
+	MaxBodySize = int64(len(body) - 1)
+	r = httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Stripe-Signature", Sign([]byte(body), time.Now()))
+	_, err = e.ReadRaw(r)
+	if !errors.Is(err, ErrWebhookTooLarge) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestParseSignatureHeader(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"", "", ErrWebhookMissingHeader},
+		{"v1=abcd", "", ErrWebhookMissingTimestamp},
+		{"t=123", "", ErrWebhookNoSignature},
+		{"t=123,v0=abcd", "", ErrWebhookNoSignature},
+		{"t=123,v1=xyz", "", ErrWebhookNoSignature},
+		{"t=123,t=456,v1=abcd", "", ErrWebhookInvalidHeader},
+		{"t=abc,v1=abcd", "", ErrWebhookInvalidHeader},
+		{"t=123,v1", "", ErrWebhookInvalidHeader},
+		{"t=123,v1=abcd", "t=123,v1=abcd", nil},
+		{"t=123, v1=abcd, v0=1234, v1=ef01", "t=123,v0=1234,v1=abcd,v1=ef01", nil},
+		{"t=123,v1=abcd,x=a=b", "t=123,v1=abcd", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			h, err := ParseSignatureHeader(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("wrong error\ngot:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && h.String() != tt.want {
+				t.Errorf("\ngot:  %s\nwant: %s", h, tt.want)
+			}
+		})
+	}
+}
@@ -20,19 +20,65 @@ import (
 )
 
 var (
-	SecretKey     = ""                       // Your Stripe secret key (sk_*).
-	PublicKey     = ""                       // Publishable key (pk_*).
-	StripeVersion = ""                       // Stripe version to use; e.g. "2020-08-27"
-	API           = "https://api.stripe.com" // API base URL.
-	DebugURL      = false                    // Show URLs as they're requested.
-	DebugReqBody  = false                    // Show body of request.
-	DebugRespBody = false                    // Show body of response
-	MaxRetry      = 30 * time.Second         // Max time to retry requests.
+	SecretKey     = ""                           // Your Stripe secret key (sk_*).
+	PublicKey     = ""                           // Publishable key (pk_*).
+	StripeVersion = ""                           // Stripe version to use; e.g. "2020-08-27"
+	API           = "https://api.stripe.com"     // API base URL.
+	ConnectAPI    = "https://connect.stripe.com" // Connect OAuth base URL.
+	ClientID      = ""                           // Connect platform client ID (ca_*), for OAuth.
+	DebugURL      = false                        // Show URLs as they're requested.
+	DebugReqBody  = false                        // Show body of request.
+	DebugRespBody = false                        // Show body of response
+	MaxRetry      = 30 * time.Second             // Max time to retry requests.
+	KeyMode       = ModeAny                      // Refuse to use SecretKey if it's not for this mode.
 )
 
 // ErrRetry is used when we've retried longer than MaxRetry.
 var ErrRetry = errors.New("retried longer than MaxRetry")
 
+// Errors for KeyMode.
+var (
+	ErrLiveKey = errors.New("zstripe.Request: SecretKey is a live key, but KeyMode is ModeTest")
+	ErrTestKey = errors.New("zstripe.Request: SecretKey is a test key, but KeyMode is ModeLive")
+)
+
+// Mode is live mode or test mode.
+type Mode int
+
+// Modes.
+const (
+	ModeAny  Mode = iota // Either live or test mode.
+	ModeLive             // Only live mode.
+	ModeTest             // Only test mode.
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeLive:
+		return "live"
+	case ModeTest:
+		return "test"
+	default:
+		return "any"
+	}
+}
+
+// allows reports if the mode allows livemode.
+func (m Mode) allows(livemode bool) bool {
+	return m == ModeAny || (m == ModeLive) == livemode
+}
+
+// checkKey checks if SecretKey is allowed by KeyMode.
+func checkKey() error {
+	switch {
+	case KeyMode == ModeTest && (strings.HasPrefix(SecretKey, "sk_live_") || strings.HasPrefix(SecretKey, "rk_live_")):
+		return ErrLiveKey
+	case KeyMode == ModeLive && (strings.HasPrefix(SecretKey, "sk_test_") || strings.HasPrefix(SecretKey, "rk_test_")):
+		return ErrTestKey
+	}
+	return nil
+}
+
 type (
 	// ID if you're interested in just retrieving the ID from a response.
 	ID struct {
@@ -45,6 +91,10 @@ type (
 		Status      string
 		StatusCode  int
 		StripeError StripeError `json:"error"`
+
+		// Description for OAuth errors from ConnectAPI; these have the error
+		// code in StripeError.Code.
+		Description string `json:"error_description"`
 	}
 
 	// StripeError is Stripe's response on errors.
@@ -68,8 +118,30 @@ func (e Error) Error() string {
 	if e.StripeError.Code != "" {
 		sc = e.StripeError.Code + ": "
 	}
+	msg := e.StripeError.Message
+	if msg == "" {
+		msg = e.Description
+	}
 	return fmt.Sprintf("code %s for %s %s (%s%s)",
-		e.Status, e.Method, e.URL, sc, e.StripeError.Message)
+		e.Status, e.Method, e.URL, sc, msg)
+}
+
+// UnmarshalJSON also accepts the error as a string, which is what the OAuth
+// endpoints use; the Type is set to "oauth_error" and the Code to the string.
+func (e *StripeError) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		*e = StripeError{Type: "oauth_error"}
+		return json.Unmarshal(b, &e.Code)
+	}
+
+	type alias StripeError
+	var a alias
+	err := json.Unmarshal(b, &a)
+	if err != nil {
+		return err
+	}
+	*e = StripeError(a)
+	return nil
 }
 
 // Body for requests.
@@ -97,8 +169,8 @@ var Client = http.Client{Timeout: 10 * time.Second}
 // A response code higher than 399 will return an Error, but won't affect the
 // behaviour of this function.
 //
-// The request body is an URL-encoded form (Stripe doesn't accept JSON), usually
-// you will want to do something like this:
+// The request body for the /v1 endpoints is an URL-encoded form, usually you
+// will want to do something like this:
 //
 //   f := make(url.Values)
 //   f.Set("name", "Martin Tournoij")
@@ -108,13 +180,31 @@ var Client = http.Client{Timeout: 10 * time.Second}
 // for many simpler application it's not really needed, which is why it's not
 // done automatically.
 //
+// The /v2 endpoints take a JSON body instead. See ListV2 and ThinEvent for some
+// helpers for the v2 API.
+//
 // The Body on the returned http.Response is closed.
 //
-// This will use the global SecretKey, which must be set.
+// This will use the global SecretKey, which must be set. ErrLiveKey or
+// ErrTestKey is returned if it's not allowed by KeyMode; for example set
+// KeyMode to ModeTest in development environments to make sure you never use a
+// live key there.
 func Request(scan interface{}, method, url string, body string) (*http.Response, error) {
+	return RequestWith(scan, method, url, body, nil)
+}
+
+// RequestWith is like Request, but with extra headers. These override the
+// default headers, so you can set e.g. your own Idempotency-Key.
+//
+// Use the Stripe-Account header to make a request on behalf of a connected
+// account; also see Event.AccountRequest.
+func RequestWith(scan interface{}, method, url string, body string, header http.Header) (*http.Response, error) {
 	if SecretKey == "" {
 		panic("zstripe.Request: must set zstripe.SecretKey")
 	}
+	if err := checkKey(); err != nil {
+		return nil, err
+	}
 
 	start := time.Now()
 
@@ -130,11 +220,21 @@ func Request(scan interface{}, method, url string, body string) (*http.Response,
 	r.Header.Add("Authorization", "Bearer "+SecretKey)
 	r.Header.Add("Idempotency-Key", rnd())
 	// TODO: /v1/files needs multipart/form-data
-	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if isV2(url) {
+		r.Header.Add("Content-Type", "application/json")
+	} else {
+		r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	}
 	if StripeVersion != "" {
 		r.Header.Add("Stripe-Version", StripeVersion)
 	}
 	r.Header.Add("User-Agent", "Go-http-client/1.1; client=zstripe")
+	for k, v := range header {
+		r.Header.Del(k)
+		for _, vv := range v {
+			r.Header.Add(k, vv)
+		}
+	}
 
 doreq:
 	if DebugURL {
@@ -190,6 +290,11 @@ doreq:
 	return resp, nil
 }
 
+// isV2 reports if this URL is for the v2 API.
+func isV2(url string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(url, API), "/v2/")
+}
+
 var max = big.NewInt(0).SetUint64(18446744073709551615)
 
 func rnd() string {
@@ -96,3 +96,33 @@ func errorContains(out error, want string) bool {
 	}
 	return strings.Contains(out.Error(), want)
 }
+
+func TestKeyMode(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer api.Close()
+	API = api.URL
+	defer func() { KeyMode = ModeAny }()
+
+	tests := []struct {
+		mode    Mode
+		key     string
+		wantErr error
+	}{
+		{ModeAny, "sk_live_x", nil},
+		{ModeAny, "sk_test_x", nil},
+		{ModeTest, "sk_test_x", nil},
+		{ModeTest, "sk_live_x", ErrLiveKey},
+		{ModeTest, "rk_live_x", ErrLiveKey},
+		{ModeLive, "sk_live_x", nil},
+		{ModeLive, "sk_test_x", ErrTestKey},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.mode, tt.key), func(t *testing.T) {
+			KeyMode, SecretKey = tt.mode, tt.key
+			_, err := Request(nil, "GET", "/", "")
+			if err != tt.wantErr {
+				t.Errorf("wrong error\ngot:  %v\nwant: %v", err, tt.wantErr)
+			}
+		})
+	}
+}